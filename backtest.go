@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/backtest"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/logger"
+)
+
+// runBacktest replays a recorded snapshot file through all detectors and prints the alerts
+//
+//	golddog backtest -data data/snapshots-20261016T000000.jsonl -horizon 5m
+func runBacktest(args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file")
+	data := fs.String("data", "", "recorded snapshot file, directory or glob")
+	horizon := fs.Duration("horizon", 5*time.Minute, "look-ahead window for the post-alert price move")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *data == "" {
+		return fmt.Errorf("-data is required")
+	}
+
+	if err := loadOfflineConfig(*configPath); err != nil {
+		return err
+	}
+
+	report, err := backtest.Run(config.GetConfig(), *data, *horizon)
+	if err != nil {
+		return err
+	}
+	return report.Write(os.Stdout)
+}
+
+// loadOfflineConfig loads the config and logger for commands that don't connect to the feed
+func loadOfflineConfig(path string) error {
+	if err := config.LoadConfig(path); err != nil {
+		return err
+	}
+	return logger.InitLogger(config.GetConfig().LoggerConfig)
+}
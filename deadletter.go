@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/notify"
+)
+
+// runDeadLetter inspects and re-drives alerts that exhausted their delivery retries
+//
+//	golddog deadletter list
+//	golddog deadletter redrive -id 3f2a9c01d4e5b6a7,9b8c7d6e5f4a3b2c
+func runDeadLetter(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: deadletter list|redrive [-config path] [-id id,...]")
+	}
+
+	fs := flag.NewFlagSet("deadletter "+args[0], flag.ContinueOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file")
+	idList := fs.String("id", "", "comma separated entry IDs to redrive (default all)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if err := loadOfflineConfig(*configPath); err != nil {
+		return err
+	}
+	cfg := config.GetConfig().Notifier
+	if cfg == nil || cfg.OutboxDir == "" {
+		return fmt.Errorf("notifier.outbox_dir is not configured")
+	}
+
+	switch args[0] {
+	case "list":
+		entries, err := notify.ReadDeadLetters(cfg.OutboxDir)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCHANNEL\tTIME\tATTEMPTS\tALERT\tERROR")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n",
+				e.ID, e.Channel, e.Time.Format("2006-01-02 15:04:05"), e.Attempts, e.Describe(), e.Error)
+		}
+		fmt.Fprintf(w, "\n%d dead letters\n", len(entries))
+		return w.Flush()
+
+	case "redrive":
+		var ids []string
+		if *idList != "" {
+			ids = strings.Split(*idList, ",")
+		}
+
+		sent, failed, err := notify.Redrive(context.Background(), cfg, ids)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("redelivered %d, still failing %d\n", sent, failed)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown deadletter command %s", args[0])
+	}
+}
@@ -2,15 +2,47 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/wangpf09/golddog/pkg/config"
 	"github.com/wangpf09/golddog/pkg/logger"
 	"github.com/wangpf09/golddog/pkg/monitor"
+	"github.com/wangpf09/golddog/pkg/source"
 )
 
+const defaultConfigPath = "conf/config.yaml"
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "backtest":
+			if err := runBacktest(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		case "sweep":
+			if err := runSweep(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		case "deadletter":
+			if err := runDeadLetter(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	runMonitor()
+}
+
+func runMonitor() {
 	// Load configuration
-	if err := config.LoadConfig("conf/config.yaml"); err != nil {
+	if err := config.LoadConfig(defaultConfigPath); err != nil {
 		panic("Failed to load config")
 	}
 
@@ -19,7 +51,12 @@ func main() {
 	}
 
 	ctx := context.Background()
-	m, err := monitor.NewMonitor(config.GetConfig())
+	src, err := source.NewSource(config.GetConfig())
+	if err != nil {
+		panic(err)
+	}
+
+	m, err := monitor.NewMonitor(config.GetConfig(), src)
 	if err != nil {
 		panic(err)
 	}
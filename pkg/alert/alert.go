@@ -1,6 +1,8 @@
 package alert
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -14,6 +16,9 @@ const (
 	AlertTypeTrend      AlertType = "Trend"
 	AlertTypeVolatility AlertType = "Volatility"
 	AlertTypeHealth     AlertType = "Health"
+	AlertTypeRule       AlertType = "Rule"
+	AlertTypeLevel      AlertType = "Level"
+	AlertTypeReport     AlertType = "Report" // Scheduled market summary, Condition holds the report name
 )
 
 // AlertSeverity represents the severity level of an alert
@@ -25,6 +30,28 @@ const (
 	SeverityCritical AlertSeverity = "Critical"
 )
 
+// Rank orders severities from Info (1) to Critical (3), 0 for unknown values
+func (s AlertSeverity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AlertState represents the lifecycle state of an alert
+type AlertState string
+
+const (
+	StateFiring   AlertState = "firing"
+	StateResolved AlertState = "resolved"
+)
+
 // AlertEvent represents a triggered alert with comprehensive information
 type AlertEvent struct {
 	Type      AlertType     `json:"type"`      // Alert type
@@ -32,13 +59,53 @@ type AlertEvent struct {
 	Symbol    string        `json:"symbol"`    // Symbol that triggered
 	Message   string        `json:"message"`   // Human-readable message
 	Timestamp time.Time     `json:"timestamp"` // When triggered
+
+	Condition   string     `json:"condition,omitempty"`   // Distinguishes alerts of one detector, e.g. "up" or a rule name
+	Fingerprint string     `json:"fingerprint,omitempty"` // Stable ID of type + symbol + condition
+	State       AlertState `json:"state,omitempty"`       // Firing unless the detector reports resolution
+	Price       float64    `json:"price,omitempty"`       // Last price when the event was created
+	StartsAt    time.Time  `json:"starts_at,omitempty"`   // When the alert started firing
+	StartPrice  float64    `json:"start_price,omitempty"` // Price when the alert started firing
+	Count       int        `json:"count,omitempty"`       // Triggers folded into the alert
+
+	// SelfResolving alerts stay open until the detector sends a resolved event,
+	// instead of resolving after a quiet period
+	SelfResolving bool `json:"self_resolving,omitempty"`
+	// OneShot alerts mark a single occurrence, e.g. a level crossing. Repeated triggers still
+	// fold into the open alert, but after the quiet period it closes without a resolved
+	// notification, since nothing has been resolved.
+	OneShot bool `json:"one_shot,omitempty"`
+}
+
+// Fingerprint returns the stable ID of an alert condition
+func Fingerprint(t AlertType, symbol, condition string) string {
+	sum := sha1.Sum([]byte(string(t) + "\x00" + symbol + "\x00" + condition))
+	return hex.EncodeToString(sum[:6])
+}
+
+// IsResolved reports whether the event closes an alert
+func (a *AlertEvent) IsResolved() bool {
+	return a.State == StateResolved
+}
+
+// Duration returns how long a resolved alert was firing
+func (a *AlertEvent) Duration() time.Duration {
+	if a.StartsAt.IsZero() {
+		return 0
+	}
+	return a.Timestamp.Sub(a.StartsAt)
 }
 
 // String returns a formatted string representation of the alert
 func (a *AlertEvent) String() string {
-	return fmt.Sprintf("[%s] [%s] %s Alert - %s: %s",
+	state := ""
+	if a.IsResolved() {
+		state = " [RESOLVED]"
+	}
+	return fmt.Sprintf("[%s] [%s]%s %s Alert - %s: %s",
 		a.Timestamp.Format("2006-01-02 15:04:05"),
 		a.Severity,
+		state,
 		a.Type,
 		a.Symbol,
 		a.Message)
@@ -62,21 +129,11 @@ func (a *AlertEvent) ToFeishuCard() map[string]interface{} {
 		color = "blue"
 	}
 
-	// Determine emoji based on type
-	emoji := "📊"
-	switch a.Type {
-	case AlertTypeJump:
-		emoji = "🚨"
-	case AlertTypeTrend:
-		emoji = "📈"
-	case AlertTypeHealth:
-		emoji = "⚠️"
-	case AlertTypeVolatility:
-		emoji = "⚡"
-	}
-
 	// Build header
-	title := fmt.Sprintf("%s %s Alert - %s", emoji, a.Type, a.Symbol)
+	title := a.Title()
+	if a.IsResolved() {
+		color = "green"
+	}
 
 	// Build fields
 	fields := []map[string]interface{}{
@@ -96,6 +153,25 @@ func (a *AlertEvent) ToFeishuCard() map[string]interface{} {
 		},
 	}
 
+	if a.IsResolved() {
+		fields = append(fields,
+			map[string]interface{}{
+				"is_short": true,
+				"text": map[string]interface{}{
+					"tag":     "lark_md",
+					"content": fmt.Sprintf("**Duration**\n%v", a.Duration().Round(time.Second)),
+				},
+			},
+			map[string]interface{}{
+				"is_short": true,
+				"text": map[string]interface{}{
+					"tag":     "lark_md",
+					"content": fmt.Sprintf("**Price**\n%.2f → %.2f (%+.2f)", a.StartPrice, a.Price, a.Price-a.StartPrice),
+				},
+			},
+		)
+	}
+
 	// Add message
 	fields = append(fields, map[string]interface{}{
 		"is_short": false,
@@ -132,7 +208,7 @@ func (a *AlertEvent) ToFeishuCard() map[string]interface{} {
 					"elements": []map[string]interface{}{
 						{
 							"tag":     "plain_text",
-							"content": fmt.Sprintf("Alert ID: %s-%d", a.Symbol, a.Timestamp.Unix()),
+							"content": fmt.Sprintf("Alert ID: %s", a.ID()),
 						},
 					},
 				},
@@ -142,3 +218,43 @@ func (a *AlertEvent) ToFeishuCard() map[string]interface{} {
 
 	return card
 }
+
+// Title returns the notification title, e.g. "🚨 Jump Alert - XAUUSD"
+func (a *AlertEvent) Title() string {
+	if a.IsResolved() {
+		return fmt.Sprintf("✅ Resolved: %s Alert - %s", a.Type, a.Symbol)
+	}
+	if a.Type == AlertTypeReport {
+		return fmt.Sprintf("%s %s Report", a.Emoji(), a.Condition)
+	}
+	return fmt.Sprintf("%s %s Alert - %s", a.Emoji(), a.Type, a.Symbol)
+}
+
+// Emoji returns the icon of the alert type
+func (a *AlertEvent) Emoji() string {
+	switch a.Type {
+	case AlertTypeJump:
+		return "🚨"
+	case AlertTypeTrend:
+		return "📈"
+	case AlertTypeHealth:
+		return "⚠️"
+	case AlertTypeVolatility:
+		return "⚡"
+	case AlertTypeRule:
+		return "🔔"
+	case AlertTypeLevel:
+		return "🎯"
+	case AlertTypeReport:
+		return "📰"
+	}
+	return "📊"
+}
+
+// ID identifies the alert in notifications; the fingerprint stays the same from firing to resolved
+func (a *AlertEvent) ID() string {
+	if a.Fingerprint == "" {
+		return fmt.Sprintf("%s-%d", a.Symbol, a.Timestamp.Unix())
+	}
+	return a.Fingerprint
+}
@@ -0,0 +1,97 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/metrics"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+// Context is the per-symbol view handed to detectors
+type Context struct {
+	Symbol   string
+	Snapshot source.NormalizedSnapshot                             // Latest snapshot
+	Prices   *metrics.RollingWindow[source.NormalizedSnapshot]     // Sampled snapshots
+	Changes  *metrics.RollingWindow[source.Derived]                // Changes between consecutive samples
+	Interval time.Duration                                         // Sampling interval, the Δt of Changes
+	Bars     map[time.Duration]*metrics.RollingWindow[metrics.Bar] // Closed OHLCV bars per timeframe
+	Now      time.Time                                             // Monitor clock, snapshot time in backtests
+}
+
+// Detector evaluates the sampled data of one symbol
+type Detector interface {
+	// Name returns the registry name of the detector
+	Name() string
+	// Evaluate returns an alert event if conditions are met, nil otherwise
+	Evaluate(ctx *Context) *AlertEvent
+}
+
+// TickDetector is implemented by detectors that must see every raw tick instead of samples.
+// The windows in ctx are not updated between samples.
+type TickDetector interface {
+	EvaluateTick(ctx *Context) *AlertEvent
+}
+
+// BarDetector is implemented by detectors that evaluate closed OHLCV bars.
+// EvaluateBar runs once per closed bar of every timeframe; ctx.Bars already contains bar.
+type BarDetector interface {
+	EvaluateBar(ctx *Context, bar metrics.Bar) *AlertEvent
+}
+
+// Factory builds the detectors of one kind for a symbol from the alerts config.
+// It returns no detectors when the kind is disabled.
+type Factory func(symbol string, cfg *config.AlertConfig) ([]Detector, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a detector factory available by name.
+// It panics if called twice with the same name.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("alert: Register factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("alert: Register called twice for detector " + name)
+	}
+	registry[name] = factory
+}
+
+// Registered returns the sorted names of all registered detectors
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build instantiates every enabled detector for symbol, in registry name order
+func Build(symbol string, cfg *config.AlertConfig) ([]Detector, error) {
+	var detectors []Detector
+	for _, name := range Registered() {
+		registryMu.RLock()
+		factory := registry[name]
+		registryMu.RUnlock()
+
+		ds, err := factory(symbol, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("detector %s: %w", name, err)
+		}
+		detectors = append(detectors, ds...)
+	}
+	return detectors, nil
+}
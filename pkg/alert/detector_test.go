@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+func TestRegisterDuplicate(t *testing.T) {
+	factory := func(string, *config.AlertConfig) ([]Detector, error) { return nil, nil }
+
+	Register("test-duplicate", factory)
+	defer func() {
+		registryMu.Lock()
+		delete(registry, "test-duplicate")
+		registryMu.Unlock()
+	}()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice did not panic")
+		}
+	}()
+	Register("test-duplicate", factory)
+}
+
+func TestBuild(t *testing.T) {
+	want := []string{"health", "jump", "level", "rules", "trend", "volatility"}
+	for _, name := range want {
+		if !slices.Contains(Registered(), name) {
+			t.Errorf("detector %s is not registered", name)
+		}
+	}
+
+	// 全部禁用时不创建任何检测器
+	cfg := config.DefaultAlertConfig()
+	cfg.Jump.Enabled = false
+	cfg.Trend.Enabled = false
+	cfg.Volatility.Enabled = false
+	cfg.Health.Enabled = false
+
+	detectors, err := Build("XAUUSD", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detectors) != 0 {
+		t.Errorf("got %d detectors with every kind disabled", len(detectors))
+	}
+
+	// 只启用跳变与价位检测器；价位只为配置了价位的品种创建
+	cfg.Jump.Enabled = true
+	cfg.Level = config.LevelConfig{Enabled: true, Symbols: map[string]config.SymbolLevels{"XAUUSD": {Upper: []float64{2700}}}}
+
+	for symbol, names := range map[string][]string{
+		"XAUUSD": {"jump", "level"},
+		"XAGUSD": {"jump"},
+	} {
+		detectors, err := Build(symbol, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range detectors {
+			got = append(got, d.Name())
+		}
+		if !slices.Equal(got, names) {
+			t.Errorf("%s: got detectors %v, want %v", symbol, got, names)
+		}
+	}
+}
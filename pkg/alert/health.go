@@ -0,0 +1,143 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/logger"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+func init() {
+	Register("health", func(symbol string, cfg *config.AlertConfig) ([]Detector, error) {
+		if !cfg.Health.Enabled {
+			return nil, nil
+		}
+		return []Detector{NewHealthDetector(symbol, cfg.Health)}, nil
+	})
+}
+
+// HealthDetector detects stale or suspended feeds: frozen price/volume/turnover,
+// snapshot timestamps drifting from the clock, or a suspended status.
+// It fires once when the feed turns unhealthy and resolves when it looks normal again.
+type HealthDetector struct {
+	symbol string
+	cfg    config.HealthConfig
+
+	last    source.NormalizedSnapshot
+	hasLast bool
+
+	unchangedPrice    int // Consecutive ticks with the same value
+	unchangedVolume   int
+	unchangedTurnover int
+
+	unhealthySince time.Time // Zero while healthy
+}
+
+// NewHealthDetector creates a new health detector from the health config section
+func NewHealthDetector(symbol string, cfg config.HealthConfig) *HealthDetector {
+	return &HealthDetector{symbol: symbol, cfg: cfg}
+}
+
+// Name returns the registry name
+func (h *HealthDetector) Name() string {
+	return "health"
+}
+
+// Evaluate does nothing on samples; health is checked on every tick by EvaluateTick
+func (h *HealthDetector) Evaluate(*Context) *AlertEvent {
+	return nil
+}
+
+// EvaluateTick checks a raw snapshot (every tick, not sampled)
+// Returns nil unless the health state changed
+func (h *HealthDetector) EvaluateTick(ctx *Context) *AlertEvent {
+	snap, now := ctx.Snapshot, ctx.Now
+	if h.hasLast {
+		h.unchangedPrice = countUnchanged(h.unchangedPrice, snap.LastPrice, h.last.LastPrice)
+		h.unchangedVolume = countUnchanged(h.unchangedVolume, snap.Volume, h.last.Volume)
+		h.unchangedTurnover = countUnchanged(h.unchangedTurnover, snap.Turnover, h.last.Turnover)
+	}
+	h.last, h.hasLast = snap, true
+
+	issues := h.issues(snap, now)
+
+	if len(issues) > 0 {
+		if !h.unhealthySince.IsZero() {
+			return nil
+		}
+		h.unhealthySince = now
+
+		severity := SeverityWarning
+		if h.cfg.CheckSuspended && snap.Status == 1 {
+			severity = SeverityCritical
+		}
+
+		logger.Debugf("health issues for %s: %v", h.symbol, issues)
+		return &AlertEvent{
+			Type:          AlertTypeHealth,
+			Severity:      severity,
+			Symbol:        h.symbol,
+			Message:       fmt.Sprintf("feed unhealthy: %s", strings.Join(issues, "; ")),
+			Timestamp:     now,
+			Condition:     "feed",
+			SelfResolving: true,
+		}
+	}
+
+	if h.unhealthySince.IsZero() {
+		return nil
+	}
+
+	downtime := now.Sub(h.unhealthySince)
+	h.unhealthySince = time.Time{}
+	return &AlertEvent{
+		Type:      AlertTypeHealth,
+		Severity:  SeverityInfo,
+		Symbol:    h.symbol,
+		Message:   fmt.Sprintf("feed back to normal after %v", downtime.Round(time.Second)),
+		Timestamp: now,
+		Condition: "feed",
+		State:     StateResolved,
+	}
+}
+
+// issues lists every violated health check; a zero threshold disables its check
+func (h *HealthDetector) issues(snap source.NormalizedSnapshot, now time.Time) []string {
+	var issues []string
+
+	if h.cfg.MaxUnchangedPrice > 0 && h.unchangedPrice >= h.cfg.MaxUnchangedPrice {
+		issues = append(issues, fmt.Sprintf("price unchanged for %d ticks", h.unchangedPrice))
+	}
+	if h.cfg.MaxUnchangedVolume > 0 && h.unchangedVolume >= h.cfg.MaxUnchangedVolume {
+		issues = append(issues, fmt.Sprintf("volume unchanged for %d ticks", h.unchangedVolume))
+	}
+	if h.cfg.MaxUnchangedTurnover > 0 && h.unchangedTurnover >= h.cfg.MaxUnchangedTurnover {
+		issues = append(issues, fmt.Sprintf("turnover unchanged for %d ticks", h.unchangedTurnover))
+	}
+
+	if h.cfg.TimestampTolerance > 0 {
+		drift := now.Sub(snap.Timestamp)
+		if drift < 0 {
+			drift = -drift
+		}
+		if drift > h.cfg.TimestampTolerance {
+			issues = append(issues, fmt.Sprintf("timestamp drift %v", drift.Round(time.Second)))
+		}
+	}
+
+	if h.cfg.CheckSuspended && snap.Status == 1 {
+		issues = append(issues, "symbol suspended")
+	}
+
+	return issues
+}
+
+func countUnchanged(count int, value, last float64) int {
+	if value == last {
+		return count + 1
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+func TestHealthDetector(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	type tick struct {
+		price  float64
+		volume float64
+		drift  time.Duration // now - snapshot timestamp
+		status int
+	}
+	tests := []struct {
+		name     string
+		cfg      config.HealthConfig
+		ticks    []tick
+		fire     int // index of the tick that fires, -1 for none
+		severity AlertSeverity
+		resolve  int // index of the tick that resolves, -1 for none
+	}{
+		{
+			name: "frozen price",
+			cfg:  config.HealthConfig{MaxUnchangedPrice: 3},
+			// 第 4 个相同价格时已连续 3 次未变
+			ticks:    []tick{{price: 1}, {price: 1}, {price: 1}, {price: 1}, {price: 1}, {price: 2}},
+			fire:     3,
+			severity: SeverityWarning,
+			resolve:  5,
+		},
+		{
+			name:     "frozen volume",
+			cfg:      config.HealthConfig{MaxUnchangedVolume: 2},
+			ticks:    []tick{{price: 1, volume: 5}, {price: 2, volume: 5}, {price: 3, volume: 5}, {price: 4, volume: 6}},
+			fire:     2,
+			severity: SeverityWarning,
+			resolve:  3,
+		},
+		{
+			name:     "changing prices",
+			cfg:      config.HealthConfig{MaxUnchangedPrice: 2},
+			ticks:    []tick{{price: 1}, {price: 2}, {price: 1}, {price: 2}},
+			fire:     -1,
+			resolve:  -1,
+			severity: SeverityWarning,
+		},
+		{
+			name:     "timestamp drift",
+			cfg:      config.HealthConfig{TimestampTolerance: time.Minute},
+			ticks:    []tick{{price: 1, drift: time.Minute}, {price: 2, drift: 2 * time.Minute}, {price: 3, drift: -3 * time.Minute}, {price: 4}},
+			fire:     1,
+			severity: SeverityWarning,
+			resolve:  3,
+		},
+		{
+			name:     "suspended",
+			cfg:      config.HealthConfig{CheckSuspended: true},
+			ticks:    []tick{{price: 1}, {price: 2, status: 1}, {price: 3, status: 1}, {price: 4}},
+			fire:     1,
+			severity: SeverityCritical,
+			resolve:  3,
+		},
+		{
+			name:    "suspended check disabled",
+			cfg:     config.HealthConfig{},
+			ticks:   []tick{{price: 1, status: 1}, {price: 1, status: 1}},
+			fire:    -1,
+			resolve: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		d := NewHealthDetector("XAUUSD", tt.cfg)
+
+		for i, tk := range tt.ticks {
+			now := t0.Add(time.Duration(i) * time.Second)
+			e := d.EvaluateTick(&Context{
+				Symbol: "XAUUSD",
+				Now:    now,
+				Snapshot: source.NormalizedSnapshot{
+					Symbol:    "XAUUSD",
+					LastPrice: tk.price,
+					Volume:    tk.volume,
+					Turnover:  float64(i),
+					Timestamp: now.Add(-tk.drift),
+					Status:    tk.status,
+				},
+			})
+
+			switch i {
+			case tt.fire:
+				if e == nil || e.IsResolved() || e.Severity != tt.severity || !e.SelfResolving || e.Symbol != "XAUUSD" {
+					t.Errorf("%s: tick %d: got %+v, want a %s firing alert", tt.name, i, e, tt.severity)
+				}
+			case tt.resolve:
+				if e == nil || !e.IsResolved() || e.Condition != "feed" {
+					t.Errorf("%s: tick %d: got %+v, want a resolved event", tt.name, i, e)
+				}
+			default:
+				// 持续异常期间不重复告警
+				if e != nil {
+					t.Errorf("%s: tick %d: unexpected event %+v", tt.name, i, e)
+				}
+			}
+		}
+	}
+}
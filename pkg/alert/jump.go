@@ -6,42 +6,72 @@ import (
 	"sync"
 	"time"
 
+	"github.com/wangpf09/golddog/pkg/config"
 	"github.com/wangpf09/golddog/pkg/logger"
 	"github.com/wangpf09/golddog/pkg/metrics"
 	"github.com/wangpf09/golddog/pkg/source"
 )
 
+func init() {
+	Register("jump", func(symbol string, cfg *config.AlertConfig) ([]Detector, error) {
+		if !cfg.Jump.Enabled {
+			return nil, nil
+		}
+		return []Detector{NewJumpDetector(symbol, cfg.Jump)}, nil
+	})
+}
+
 // JumpDetector detects price jump (spike) events
 type JumpDetector struct {
-	threshold  float64       // Minimum price change to trigger alert (absolute or percent)
-	cooldown   time.Duration // Minimum time between alerts for same symbol
-	usePercent bool          // If true, threshold is percentage; if false, absolute
-	mu         sync.RWMutex  // Protects symbolStates
+	symbol     string
+	threshold  float64      // Minimum price change to trigger alert (absolute or percent)
+	usePercent bool         // If true, threshold is percentage; if false, absolute
+	zThreshold float64      // z-score of Δp that triggers an alert
+	stdFloor   float64      // Minimum stddev of Δp, filters quiet markets
+	mu         sync.RWMutex // Protects symbolStates
 }
 
-// NewJumpDetector creates a new jump detector
-func NewJumpDetector() *JumpDetector {
+// NewJumpDetector creates a new jump detector from the jump config section
+func NewJumpDetector(symbol string, cfg config.JumpConfig) *JumpDetector {
 	return &JumpDetector{
-		threshold:  1.5,
-		cooldown:   time.Minute * 1,
-		usePercent: true,
+		symbol:     symbol,
+		threshold:  cfg.Threshold,
+		usePercent: cfg.UsePercent,
+		zThreshold: cfg.ZThreshold,
+		stdFloor:   cfg.StdFloor,
 	}
 }
 
+// Name returns the registry name
+func (d *JumpDetector) Name() string {
+	return "jump"
+}
+
 // Evaluate evaluates a snapshot and returns an alert event if conditions are met
 // Returns nil if no alert should be triggered
-func (d *JumpDetector) Evaluate(window *metrics.RollingWindow[source.Derived]) *AlertEvent {
+func (d *JumpDetector) Evaluate(ctx *Context) *AlertEvent {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	return d.zJump(window)
+	return d.zJump(ctx.Changes, ctx.Now)
+}
+
+// exceedsThreshold checks the minimum |Δp| gate, absolute or relative to the price
+func (d *JumpDetector) exceedsThreshold(change source.Derived) bool {
+	if d.threshold <= 0 {
+		return true
+	}
+	if d.usePercent {
+		return math.Abs(change.PriceChangeRate)*100 >= d.threshold
+	}
+	return math.Abs(change.PriceChange) >= d.threshold
 }
 
 // JumpAlert z_jump = |price_change - mean(price_change)| / stddev(price_change)
-func (d *JumpDetector) zJump(window *metrics.RollingWindow[source.Derived]) *AlertEvent {
+func (d *JumpDetector) zJump(window *metrics.RollingWindow[source.Derived], now time.Time) *AlertEvent {
 	values := priceChange(window)
 	std := metrics.StdDev(values)
-	if std < 0.2 { // 防抖
+	if std < d.stdFloor { // 防抖
 		return nil
 	}
 
@@ -49,12 +79,21 @@ func (d *JumpDetector) zJump(window *metrics.RollingWindow[source.Derived]) *Ale
 
 	logger.Debugf("z std: %.2f, lat: %.2f", std, z)
 
-	if z >= 4.0 {
+	latest, _ := window.Latest()
+	if z >= d.zThreshold && d.exceedsThreshold(latest) {
+		dir := "up"
+		if latest.PriceChange < 0 {
+			dir = "down"
+		}
+
 		return &AlertEvent{
 			Type:      AlertTypeJump,
+			Condition: dir,
+			Symbol:    d.symbol,
 			Severity:  SeverityCritical,
 			Message:   fmt.Sprintf("price jump detected: Δp=%.2f, z=%.2f", values[len(values)-1], z),
-			Timestamp: time.Now(),
+			Timestamp: now,
+			OneShot:   true,
 		}
 	}
 	return nil
@@ -0,0 +1,157 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+func init() {
+	Register("level", func(symbol string, cfg *config.AlertConfig) ([]Detector, error) {
+		if !cfg.Level.Enabled {
+			return nil, nil
+		}
+		levels, ok := cfg.Level.Symbols[symbol]
+		if !ok {
+			return nil, nil
+		}
+		return []Detector{NewLevelDetector(symbol, cfg.Level, levels)}, nil
+	})
+}
+
+// defaultHysteresisRate is the re-arm band relative to the level when none is configured,
+// about 0.5 for gold at 2700, so a price hovering at a level does not flap
+const defaultHysteresisRate = 0.0002
+
+// priceLevel is one configured level and whether it may fire
+type priceLevel struct {
+	value float64
+	upper bool // Fires when price rises to value, otherwise when it falls to value
+	armed bool
+	seen  bool // armed has been initialized from the first observed price
+}
+
+// LevelDetector fires once when price crosses a configured level and re-arms
+// only after price has moved back beyond the hysteresis band
+type LevelDetector struct {
+	symbol     string
+	unit       string // Price unit of the levels, empty means the quote unit
+	hysteresis float64
+	levels     []*priceLevel
+}
+
+// NewLevelDetector creates a new level detector for the levels of one symbol
+func NewLevelDetector(symbol string, cfg config.LevelConfig, sl config.SymbolLevels) *LevelDetector {
+	d := &LevelDetector{
+		symbol:     symbol,
+		unit:       sl.Unit,
+		hysteresis: cfg.Hysteresis,
+	}
+	if sl.Hysteresis > 0 {
+		d.hysteresis = sl.Hysteresis
+	}
+
+	for _, v := range sl.Upper {
+		d.levels = append(d.levels, &priceLevel{value: v, upper: true})
+	}
+	for _, v := range sl.Lower {
+		d.levels = append(d.levels, &priceLevel{value: v})
+	}
+	return d
+}
+
+// Name returns the registry name
+func (d *LevelDetector) Name() string {
+	return "level"
+}
+
+// Evaluate does nothing on samples; levels are checked on every tick by EvaluateTick
+func (d *LevelDetector) Evaluate(*Context) *AlertEvent {
+	return nil
+}
+
+// EvaluateTick checks every level against the latest price
+func (d *LevelDetector) EvaluateTick(ctx *Context) *AlertEvent {
+	price, unit := d.price(ctx.Snapshot)
+	if price <= 0 {
+		return nil
+	}
+
+	var above, below []string
+	for _, l := range d.levels {
+		if d.update(l, price) {
+			if l.upper {
+				above = append(above, fmt.Sprintf("%.2f", l.value))
+			} else {
+				below = append(below, fmt.Sprintf("%.2f", l.value))
+			}
+		}
+	}
+
+	if len(above) == 0 && len(below) == 0 {
+		return nil
+	}
+
+	var parts []string
+	if len(above) > 0 {
+		parts = append(parts, "above "+strings.Join(above, ", "))
+	}
+	if len(below) > 0 {
+		parts = append(parts, "below "+strings.Join(below, ", "))
+	}
+
+	return &AlertEvent{
+		Type:      AlertTypeLevel,
+		Condition: strings.Join(parts, " and "),
+		Symbol:    d.symbol,
+		Severity:  SeverityWarning,
+		Message:   fmt.Sprintf("price crossed %s %s, last=%.2f", strings.Join(parts, " and "), unit, price),
+		Timestamp: ctx.Now,
+		OneShot:   true,
+	}
+}
+
+// update advances the state of one level and reports whether it fired
+func (d *LevelDetector) update(l *priceLevel, price float64) bool {
+	band := d.hysteresis
+	if band <= 0 {
+		band = l.value * defaultHysteresisRate
+	}
+
+	crossed := price >= l.value
+	rearm := price <= l.value-band
+	if !l.upper {
+		crossed = price <= l.value
+		rearm = price >= l.value+band
+	}
+
+	// 启动时已在价位之外的不触发，需先回到区间内
+	if !l.seen {
+		l.seen = true
+		l.armed = !crossed
+		return false
+	}
+
+	switch {
+	case l.armed && crossed:
+		l.armed = false
+		return true
+	case !l.armed && rearm && !crossed:
+		l.armed = true
+	}
+	return false
+}
+
+// price returns the last price in the unit of the levels, 0 when it cannot be converted
+func (d *LevelDetector) price(snap source.NormalizedSnapshot) (float64, string) {
+	if d.unit == "" {
+		return snap.LastPrice, snap.GetUnit()
+	}
+	price, ok := snap.Price(d.unit)
+	if !ok {
+		return 0, d.unit
+	}
+	return price, d.unit
+}
@@ -0,0 +1,62 @@
+package alert
+
+import (
+	"testing"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+func TestLevelDetectorHysteresis(t *testing.T) {
+	d := NewLevelDetector("XAUUSD",
+		config.LevelConfig{Hysteresis: 2},
+		config.SymbolLevels{Upper: []float64{2700}, Lower: []float64{2600}})
+
+	tests := []struct {
+		price float64
+		fire  bool
+	}{
+		{2690, false}, // first observation only arms
+		{2700, true},  // crosses the upper level
+		{2705, false},
+		{2699, false}, // still inside the band
+		{2701, false},
+		{2697, false}, // leaves the band, re-armed
+		{2702, true},
+		{2601, false},
+		{2600, true}, // crosses the lower level
+		{2599, false},
+	}
+
+	for i, tt := range tests {
+		ctx := &Context{Snapshot: source.NormalizedSnapshot{LastPrice: tt.price}}
+		e := d.EvaluateTick(ctx)
+		if (e != nil) != tt.fire {
+			t.Errorf("step %d price %.0f: fired=%v, want %v", i, tt.price, e != nil, tt.fire)
+		}
+	}
+}
+
+func TestLevelDetectorDefaultHysteresis(t *testing.T) {
+	// 未配置回撤带时使用价位的 0.02%，2700 约 0.54
+	d := NewLevelDetector("XAUUSD", config.LevelConfig{}, config.SymbolLevels{Upper: []float64{2700}})
+
+	prices := []float64{2690, 2700, 2699.8, 2700.1, 2699.9, 2700, 2699.4, 2700}
+	fire := []bool{false, true, false, false, false, false, false, true}
+	for i, p := range prices {
+		e := d.EvaluateTick(&Context{Snapshot: source.NormalizedSnapshot{LastPrice: p}})
+		if (e != nil) != fire[i] {
+			t.Errorf("step %d price %.1f: fired=%v, want %v", i, p, e != nil, fire[i])
+		}
+	}
+}
+
+func TestLevelDetectorStartsBeyondLevel(t *testing.T) {
+	d := NewLevelDetector("XAUUSD", config.LevelConfig{}, config.SymbolLevels{Upper: []float64{2700}})
+
+	for _, price := range []float64{2710, 2720} {
+		if e := d.EvaluateTick(&Context{Snapshot: source.NormalizedSnapshot{LastPrice: price}}); e != nil {
+			t.Errorf("unexpected alert at %.0f: %s", price, e.Message)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/logger"
+)
+
+// Tracker keeps the open alerts keyed by fingerprint and drives their lifecycle:
+// the first trigger fires, repeated triggers update the open alert, and the alert
+// resolves when its detector says so or after a quiet period without triggers.
+// One-shot alerts close silently after the quiet period.
+type Tracker struct {
+	resolveAfter time.Duration
+	open         map[string]*trackedAlert
+}
+
+type trackedAlert struct {
+	event    *AlertEvent // The firing event as notified
+	lastSeen time.Time
+	price    float64 // Latest price seen for the alert
+}
+
+// NewTracker creates a tracker resolving quiet alerts after resolveAfter
+func NewTracker(resolveAfter time.Duration) *Tracker {
+	return &Tracker{
+		resolveAfter: resolveAfter,
+		open:         make(map[string]*trackedAlert),
+	}
+}
+
+// Process applies an event to the open alerts and returns the event to notify,
+// or nil when it only updated an open alert or resolves nothing
+func (t *Tracker) Process(e *AlertEvent) *AlertEvent {
+	if e.Fingerprint == "" {
+		e.Fingerprint = Fingerprint(e.Type, e.Symbol, e.Condition)
+	}
+
+	open, ok := t.open[e.Fingerprint]
+
+	if e.IsResolved() {
+		if !ok {
+			return nil
+		}
+		delete(t.open, e.Fingerprint)
+		return resolvedFrom(open.event, e.Timestamp, e.Price, open.event.Count, e.Message)
+	}
+
+	if ok {
+		open.lastSeen = e.Timestamp
+		open.price = e.Price
+		open.event.Count++
+		if e.Severity.Rank() > open.event.Severity.Rank() {
+			open.event.Severity = e.Severity
+		}
+		logger.Debugf("alert %s still firing (%d triggers): %s", e.Fingerprint, open.event.Count, e.Message)
+		return nil
+	}
+
+	e.State = StateFiring
+	e.StartsAt = e.Timestamp
+	e.StartPrice = e.Price
+	e.Count = 1
+
+	// 保存副本，发送出去的事件不再被修改
+	tracked := *e
+	t.open[e.Fingerprint] = &trackedAlert{event: &tracked, lastSeen: e.Timestamp, price: e.Price}
+	return e
+}
+
+// Expire resolves alerts that have not been triggered for the resolve period.
+// One-shot alerts are closed without a resolved event; their fingerprints are returned
+// in closed so that later stages can forget them too.
+// price returns the latest price of a symbol, or 0 when unknown.
+func (t *Tracker) Expire(now time.Time, price func(symbol string) float64) (resolved []*AlertEvent, closed []string) {
+	if t.resolveAfter <= 0 {
+		return nil, nil
+	}
+
+	for fp, open := range t.open {
+		if open.event.SelfResolving || now.Sub(open.lastSeen) < t.resolveAfter {
+			continue
+		}
+		delete(t.open, fp)
+
+		if open.event.OneShot {
+			logger.Debugf("alert %s closed after %v without triggers", fp, t.resolveAfter)
+			closed = append(closed, fp)
+			continue
+		}
+
+		last := open.price
+		if p := price(open.event.Symbol); p > 0 {
+			last = p
+		}
+		msg := fmt.Sprintf("no trigger for %v", t.resolveAfter)
+		resolved = append(resolved, resolvedFrom(open.event, now, last, open.event.Count, msg))
+	}
+
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].StartsAt.Before(resolved[j].StartsAt) })
+	return resolved, closed
+}
+
+// Open returns the number of firing alerts
+func (t *Tracker) Open() int {
+	return len(t.open)
+}
+
+// resolvedFrom builds the resolved notification of a firing alert
+func resolvedFrom(firing *AlertEvent, at time.Time, price float64, count int, reason string) *AlertEvent {
+	e := &AlertEvent{
+		Type:        firing.Type,
+		Severity:    firing.Severity,
+		Symbol:      firing.Symbol,
+		Condition:   firing.Condition,
+		Fingerprint: firing.Fingerprint,
+		State:       StateResolved,
+		Timestamp:   at,
+		Price:       price,
+		StartsAt:    firing.StartsAt,
+		StartPrice:  firing.StartPrice,
+		Count:       count,
+	}
+
+	e.Message = fmt.Sprintf("resolved after %v (%d triggers): %s", e.Duration().Round(time.Second), count, reason)
+	if e.StartPrice > 0 && e.Price > 0 {
+		e.Message += fmt.Sprintf(", price %.2f → %.2f (%+.2f)", e.StartPrice, e.Price, e.Price-e.StartPrice)
+	}
+	return e
+}
@@ -0,0 +1,108 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrackerLifecycle(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	tr := NewTracker(5 * time.Minute)
+
+	event := func(at time.Duration, severity AlertSeverity, price float64) *AlertEvent {
+		return &AlertEvent{
+			Type:          AlertTypeRule,
+			Symbol:        "XAUUSD",
+			Condition:     "above-2700",
+			Severity:      severity,
+			Price:         price,
+			Timestamp:     t0.Add(at),
+			SelfResolving: true,
+		}
+	}
+
+	// 首次触发
+	e := tr.Process(event(0, SeverityInfo, 2700))
+	if e == nil || e.State != StateFiring || e.Count != 1 || !e.StartsAt.Equal(t0) || e.Fingerprint == "" {
+		t.Fatalf("first trigger = %+v, want a firing alert", e)
+	}
+	fp := e.Fingerprint
+
+	// 重复触发只更新已打开的告警，级别只升不降
+	for i, sev := range []AlertSeverity{SeverityWarning, SeverityInfo} {
+		if e := tr.Process(event(time.Duration(i+1)*time.Minute, sev, 2705)); e != nil {
+			t.Fatalf("repeated trigger notified: %+v", e)
+		}
+	}
+	if tr.Open() != 1 {
+		t.Fatalf("open alerts = %d, want 1", tr.Open())
+	}
+
+	// 自行恢复的告警不因静默超时而恢复
+	if got, _ := tr.Expire(t0.Add(time.Hour), func(string) float64 { return 0 }); len(got) != 0 {
+		t.Fatalf("self-resolving alert expired: %+v", got)
+	}
+
+	resolved := event(10*time.Minute, SeverityInfo, 2690)
+	resolved.State = StateResolved
+	e = tr.Process(resolved)
+	if e == nil || !e.IsResolved() || e.Fingerprint != fp {
+		t.Fatalf("resolve = %+v, want the resolved alert %s", e, fp)
+	}
+	if e.Count != 3 || e.Severity != SeverityWarning || e.Duration() != 10*time.Minute {
+		t.Errorf("resolved count %d severity %s duration %v, want 3 Warning 10m", e.Count, e.Severity, e.Duration())
+	}
+	if e.StartPrice != 2700 || e.Price != 2690 || !strings.Contains(e.Message, "2700.00 → 2690.00") {
+		t.Errorf("resolved prices %v → %v: %s", e.StartPrice, e.Price, e.Message)
+	}
+	if tr.Open() != 0 {
+		t.Errorf("open alerts = %d after resolving, want 0", tr.Open())
+	}
+
+	// 没有打开的告警时恢复事件被忽略
+	if e := tr.Process(resolved); e != nil {
+		t.Errorf("resolving a closed alert notified: %+v", e)
+	}
+}
+
+func TestTrackerExpire(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	tr := NewTracker(5 * time.Minute)
+
+	trend := &AlertEvent{Type: AlertTypeTrend, Symbol: "XAUUSD", Condition: "up", Severity: SeverityInfo, Price: 2700, Timestamp: t0}
+	level := &AlertEvent{Type: AlertTypeLevel, Symbol: "XAUUSD", Condition: "above 2700.00", Severity: SeverityWarning, Price: 2700, Timestamp: t0, OneShot: true}
+	for _, e := range []*AlertEvent{trend, level} {
+		if tr.Process(e) == nil {
+			t.Fatalf("%s did not fire", e.Type)
+		}
+	}
+
+	// 静默期内再次触发，延后恢复时间
+	tr.Process(&AlertEvent{Type: AlertTypeTrend, Symbol: "XAUUSD", Condition: "up", Severity: SeverityInfo, Price: 2704, Timestamp: t0.Add(2 * time.Minute)})
+
+	price := func(symbol string) float64 { return 2710 }
+	got, closed := tr.Expire(t0.Add(6*time.Minute), price)
+	if len(got) != 0 || len(closed) != 1 || closed[0] != level.Fingerprint {
+		t.Fatalf("expired %d alerts and closed %v, want only the level alert closed silently", len(got), closed)
+	}
+	if tr.Open() != 1 {
+		t.Fatalf("open alerts = %d, want the trend alert", tr.Open())
+	}
+
+	got, closed = tr.Expire(t0.Add(7*time.Minute), price)
+	if len(got) != 1 || len(closed) != 0 {
+		t.Fatalf("expired %d alerts, want 1", len(got))
+	}
+	e := got[0]
+	if e.Type != AlertTypeTrend || !e.IsResolved() || e.Count != 2 || e.Price != 2710 || !strings.Contains(e.Message, "no trigger for 5m0s") {
+		t.Errorf("expired = %+v", e)
+	}
+
+	// 关闭后的一次性告警再次触发时重新通知
+	level.Timestamp = t0.Add(8 * time.Minute)
+	level.State, level.Count = "", 0
+	if e := tr.Process(level); e == nil || e.State != StateFiring {
+		t.Errorf("level alert after closing = %+v, want firing", e)
+	}
+}
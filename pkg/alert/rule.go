@@ -0,0 +1,240 @@
+package alert
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/logger"
+	"github.com/wangpf09/golddog/pkg/rule"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+func init() {
+	Register("rules", func(symbol string, cfg *config.AlertConfig) ([]Detector, error) {
+		var detectors []Detector
+		for _, rc := range cfg.Rules {
+			if !rc.IsEnabled() {
+				continue
+			}
+			if len(rc.Symbols) > 0 && symbol != "" && !slices.Contains(rc.Symbols, symbol) {
+				continue
+			}
+
+			d, err := NewRuleDetector(symbol, rc)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rc.Name, err)
+			}
+			detectors = append(detectors, d)
+		}
+		return detectors, nil
+	})
+}
+
+// ruleFields maps rule expression fields to snapshot values
+var ruleFields = map[string]func(snap source.NormalizedSnapshot, d source.Derived) float64{
+	"price":             func(s source.NormalizedSnapshot, _ source.Derived) float64 { return s.LastPrice },
+	"price_cny":         func(s source.NormalizedSnapshot, _ source.Derived) float64 { return s.LastPriceCNY },
+	"open":              func(s source.NormalizedSnapshot, _ source.Derived) float64 { return s.Open },
+	"high":              func(s source.NormalizedSnapshot, _ source.Derived) float64 { return s.High },
+	"low":               func(s source.NormalizedSnapshot, _ source.Derived) float64 { return s.Low },
+	"volume":            func(s source.NormalizedSnapshot, _ source.Derived) float64 { return s.Volume },
+	"turnover":          func(s source.NormalizedSnapshot, _ source.Derived) float64 { return s.Turnover },
+	"status":            func(s source.NormalizedSnapshot, _ source.Derived) float64 { return float64(s.Status) },
+	"price_change":      func(_ source.NormalizedSnapshot, d source.Derived) float64 { return d.PriceChange },
+	"price_change_rate": func(_ source.NormalizedSnapshot, d source.Derived) float64 { return d.PriceChangeRate },
+	"volume_delta":      func(_ source.NormalizedSnapshot, d source.Derived) float64 { return d.VolumeDelta },
+}
+
+func isRuleField(name string) bool {
+	_, ok := ruleFields[name]
+	return ok
+}
+
+// RuleDetector evaluates one configured rule expression for one symbol.
+// It fires when the condition becomes true and resolves once it is false again. Crossing
+// rules hold for a single sample, so they fire one-shot alerts on every match instead.
+type RuleDetector struct {
+	symbol   string
+	name     string
+	severity AlertSeverity
+	program  *rule.Program
+	message  *template.Template
+	oneShot  bool
+	active   bool
+}
+
+// ruleMessage is the data available to rule message templates
+type ruleMessage struct {
+	Rule     string
+	Expr     string
+	Symbol   string
+	Price    float64
+	PriceCNY float64
+	Time     time.Time
+}
+
+// NewRuleDetector compiles a rule config for symbol
+func NewRuleDetector(symbol string, cfg config.RuleConfig) (*RuleDetector, error) {
+	program, err := rule.Compile(cfg.Expr, isRuleField)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expr: %w", err)
+	}
+
+	text := cfg.Message
+	if text == "" {
+		text = `rule {{.Rule}} matched: {{.Expr}} (price={{printf "%.2f" .Price}})`
+	}
+	message, err := template.New(cfg.Name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message template: %w", err)
+	}
+
+	severity := AlertSeverity(cfg.Severity)
+	if severity == "" {
+		severity = SeverityWarning
+	}
+
+	return &RuleDetector{
+		symbol:   symbol,
+		name:     cfg.Name,
+		severity: severity,
+		program:  program,
+		message:  message,
+		oneShot:  program.Edge(),
+	}, nil
+}
+
+// Name returns the registry name
+func (r *RuleDetector) Name() string {
+	return "rules"
+}
+
+// Evaluate returns an alert when the rule condition turns true
+func (r *RuleDetector) Evaluate(ctx *Context) *AlertEvent {
+	matched := r.program.Eval(&ruleEnv{ctx: ctx})
+	if r.oneShot {
+		// 穿越只在单个样本成立，不发送恢复通知
+		if !matched {
+			return nil
+		}
+		return r.fire(ctx)
+	}
+	if !matched {
+		if !r.active {
+			return nil
+		}
+		r.active = false
+		return &AlertEvent{
+			Type:      AlertTypeRule,
+			Symbol:    r.symbol,
+			Severity:  r.severity,
+			Message:   fmt.Sprintf("rule %s no longer matches", r.name),
+			Timestamp: ctx.Now,
+			Condition: r.name,
+			State:     StateResolved,
+		}
+	}
+	if r.active {
+		return nil
+	}
+	r.active = true
+	return r.fire(ctx)
+}
+
+// fire renders the rule message into a firing alert
+func (r *RuleDetector) fire(ctx *Context) *AlertEvent {
+	var buf bytes.Buffer
+	err := r.message.Execute(&buf, ruleMessage{
+		Rule:     r.name,
+		Expr:     r.program.String(),
+		Symbol:   r.symbol,
+		Price:    ctx.Snapshot.LastPrice,
+		PriceCNY: ctx.Snapshot.LastPriceCNY,
+		Time:     ctx.Now,
+	})
+	if err != nil {
+		logger.Warnf("rule %s: failed to render message: %v", r.name, err)
+		buf.Reset()
+		buf.WriteString(fmt.Sprintf("rule %s matched: %s", r.name, r.program))
+	}
+
+	return &AlertEvent{
+		Type:          AlertTypeRule,
+		Symbol:        r.symbol,
+		Severity:      r.severity,
+		Message:       buf.String(),
+		Timestamp:     ctx.Now,
+		Condition:     r.name,
+		SelfResolving: !r.oneShot,
+		OneShot:       r.oneShot,
+	}
+}
+
+// ruleEnv exposes the sampled windows of a detector context to rule expressions
+type ruleEnv struct {
+	ctx *Context
+}
+
+func (e *ruleEnv) Field(name string) (float64, bool) {
+	f, ok := ruleFields[name]
+	if !ok {
+		return 0, false
+	}
+	d, _ := e.ctx.Changes.Latest()
+	return f(e.ctx.Snapshot, d), true
+}
+
+func (e *ruleEnv) Series(name string, window time.Duration) []float64 {
+	f, ok := ruleFields[name]
+	if !ok {
+		return nil
+	}
+
+	prices := e.ctx.Prices.Values()
+	changes := e.ctx.Changes.Values()
+	offset := len(prices) - len(changes) // changes[i] ends at prices[i+offset]
+	cutoff := e.ctx.Snapshot.Timestamp.Add(-window)
+
+	start := len(prices)
+	for start > 0 && !prices[start-1].Timestamp.Before(cutoff) {
+		start--
+	}
+
+	values := make([]float64, 0, len(prices)-start)
+	for i := start; i < len(prices); i++ {
+		var d source.Derived
+		if j := i - offset; j >= 0 {
+			d = changes[j]
+		}
+		values = append(values, f(prices[i], d))
+	}
+	return values
+}
+
+func (e *ruleEnv) At(name string, ago time.Duration) (float64, bool) {
+	f, ok := ruleFields[name]
+	if !ok {
+		return 0, false
+	}
+
+	prices := e.ctx.Prices.Values()
+	changes := e.ctx.Changes.Values()
+	offset := len(prices) - len(changes)
+	cutoff := e.ctx.Snapshot.Timestamp.Add(-ago)
+
+	for i := len(prices) - 1; i >= 0; i-- {
+		if prices[i].Timestamp.After(cutoff) {
+			continue
+		}
+		var d source.Derived
+		if j := i - offset; j >= 0 {
+			d = changes[j]
+		}
+		return f(prices[i], d), true
+	}
+	return 0, false
+}
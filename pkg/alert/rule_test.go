@@ -0,0 +1,53 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/metrics"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+func TestRuleDetectorCrossing(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	prices := []float64{2690, 2705, 2710, 2695, 2701}
+
+	tests := []struct {
+		expr    string
+		oneShot bool
+		fire    []bool
+		ended   []bool // resolved events
+	}{
+		// 穿越只在单个样本成立：每次穿越一条一次性告警，没有恢复通知
+		{"crosses_above(price, 2700)", true, []bool{false, true, false, false, true}, []bool{false, false, false, false, false}},
+		// 状态条件持续成立，条件不再满足时恢复
+		{"price > 2700", false, []bool{false, true, false, false, true}, []bool{false, false, false, true, false}},
+	}
+
+	for _, tt := range tests {
+		d, err := NewRuleDetector("XAUUSD", config.RuleConfig{Name: "breakout", Expr: tt.expr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		window := metrics.NewRollingWindow[source.NormalizedSnapshot](10)
+		changes := metrics.NewRollingWindow[source.Derived](10)
+
+		for i, p := range prices {
+			now := t0.Add(time.Duration(i) * 12 * time.Second)
+			snap := source.NormalizedSnapshot{Symbol: "XAUUSD", LastPrice: p, Timestamp: now}
+			window.Push(snap)
+			e := d.Evaluate(&Context{Symbol: "XAUUSD", Snapshot: snap, Prices: window, Changes: changes, Now: now})
+
+			fired := e != nil && !e.IsResolved()
+			ended := e != nil && e.IsResolved()
+			if fired != tt.fire[i] || ended != tt.ended[i] {
+				t.Errorf("%s at %v: got %+v", tt.expr, p, e)
+				continue
+			}
+			if fired && (e.OneShot != tt.oneShot || e.SelfResolving == tt.oneShot) {
+				t.Errorf("%s: one-shot %v self-resolving %v", tt.expr, e.OneShot, e.SelfResolving)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+// Suppressor decides which lifecycle events reach the notifier. It applies the
+// per-fingerprint cooldowns of the alerts config and holds back lower-severity
+// alerts of a symbol while a higher-severity one is firing or has just fired.
+type Suppressor struct {
+	cooldown map[AlertType]time.Duration // Minimum time between firings of one fingerprint
+	suppress map[AlertType]time.Duration // Extra suppression after a higher-severity alert fired
+
+	active    map[string]*AlertEvent                   // Notified firing alerts by fingerprint
+	lastFired map[string]*AlertEvent                   // Last notified firing by fingerprint, kept for its cooldown
+	latest    map[string]map[AlertSeverity]*AlertEvent // Latest notified firing per symbol and severity, kept for the suppression window
+}
+
+// NewSuppressor creates a suppressor from the cooldown settings of the alerts config
+func NewSuppressor(cfg *config.AlertConfig) *Suppressor {
+	return &Suppressor{
+		cooldown: map[AlertType]time.Duration{
+			AlertTypeJump:  cfg.Jump.Cooldown,
+			AlertTypeTrend: cfg.Trend.Cooldown,
+		},
+		suppress: map[AlertType]time.Duration{
+			AlertTypeTrend: cfg.Trend.SuppressionDuration,
+		},
+		active:    make(map[string]*AlertEvent),
+		lastFired: make(map[string]*AlertEvent),
+		latest:    make(map[string]map[AlertSeverity]*AlertEvent),
+	}
+}
+
+// Allow reports whether e should be notified, and the reason when it should not.
+// Events must have passed the Tracker, so firing events are new alerts.
+func (s *Suppressor) Allow(e *AlertEvent) (bool, string) {
+	if e.IsResolved() {
+		// 触发时被抑制的告警，恢复时也不通知
+		if _, ok := s.active[e.Fingerprint]; !ok {
+			return false, "firing notification was suppressed"
+		}
+		delete(s.active, e.Fingerprint)
+		return true, ""
+	}
+
+	if last, ok := s.lastFired[e.Fingerprint]; ok {
+		if cd := s.cooldown[e.Type]; cd > 0 && e.Timestamp.Sub(last.Timestamp) < cd {
+			return false, fmt.Sprintf("cooldown %v, last fired %v ago", cd, e.Timestamp.Sub(last.Timestamp).Round(time.Second))
+		}
+	}
+
+	if reason := s.suppressedBy(e); reason != "" {
+		return false, reason
+	}
+
+	s.active[e.Fingerprint] = e
+	s.lastFired[e.Fingerprint] = e
+	if e.Type != AlertTypeHealth {
+		if s.latest[e.Symbol] == nil {
+			s.latest[e.Symbol] = make(map[AlertSeverity]*AlertEvent)
+		}
+		s.latest[e.Symbol][e.Severity] = e
+	}
+	return true, ""
+}
+
+// Close forgets a firing alert that ended without a resolved event, e.g. a one-shot alert
+// closed by the Tracker, so that it no longer holds back lower-severity alerts
+func (s *Suppressor) Close(fingerprint string) {
+	delete(s.active, fingerprint)
+}
+
+// Prune forgets the firings whose cooldown and suppression window have passed at now.
+// Firing alerts stay until they resolve or are closed.
+func (s *Suppressor) Prune(now time.Time) {
+	for fp, e := range s.lastFired {
+		if now.Sub(e.Timestamp) >= s.cooldown[e.Type] {
+			delete(s.lastFired, fp)
+		}
+	}
+
+	var window time.Duration
+	for _, d := range s.suppress {
+		window = max(window, d)
+	}
+	for symbol, bySeverity := range s.latest {
+		for sev, e := range bySeverity {
+			if now.Sub(e.Timestamp) >= window {
+				delete(bySeverity, sev)
+			}
+		}
+		if len(bySeverity) == 0 {
+			delete(s.latest, symbol)
+		}
+	}
+}
+
+// suppressedBy returns why e is held back by a higher-severity alert of the same symbol.
+// Health alerts describe the feed rather than the market and neither suppress nor get suppressed.
+func (s *Suppressor) suppressedBy(e *AlertEvent) string {
+	if e.Type == AlertTypeHealth {
+		return ""
+	}
+
+	for _, a := range s.active {
+		if a.Type == AlertTypeHealth || a.Symbol != e.Symbol || a.Severity.Rank() <= e.Severity.Rank() {
+			continue
+		}
+		return fmt.Sprintf("%s %s alert %s is firing", a.Severity, a.Type, a.Fingerprint)
+	}
+
+	// Trend 等在高级别告警之后的一段时间内仍然抑制
+	d := s.suppress[e.Type]
+	if d <= 0 {
+		return ""
+	}
+	for sev, h := range s.latest[e.Symbol] {
+		if sev.Rank() <= e.Severity.Rank() || e.Timestamp.Sub(h.Timestamp) >= d {
+			continue
+		}
+		return fmt.Sprintf("%s %s alert %s fired %v ago (suppression %v)",
+			h.Severity, h.Type, h.Fingerprint, e.Timestamp.Sub(h.Timestamp).Round(time.Second), d)
+	}
+	return ""
+}
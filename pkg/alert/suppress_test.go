@@ -0,0 +1,77 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+func TestSuppressor(t *testing.T) {
+	cfg := config.DefaultAlertConfig()
+	cfg.Jump.Cooldown = 10 * time.Minute
+	cfg.Trend.SuppressionDuration = 15 * time.Minute
+	s := NewSuppressor(cfg)
+
+	t0 := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	event := func(typ AlertType, sev AlertSeverity, cond string, state AlertState, at time.Duration) *AlertEvent {
+		return &AlertEvent{
+			Type:        typ,
+			Severity:    sev,
+			Symbol:      "XAUUSD",
+			Fingerprint: Fingerprint(typ, "XAUUSD", cond),
+			State:       state,
+			Timestamp:   t0.Add(at),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		event *AlertEvent
+		allow bool
+	}{
+		{"jump fires", event(AlertTypeJump, SeverityCritical, "up", StateFiring, 0), true},
+		{"trend while jump is firing", event(AlertTypeTrend, SeverityInfo, "up", StateFiring, time.Minute), false},
+		{"trend resolved after suppressed firing", event(AlertTypeTrend, SeverityInfo, "up", StateResolved, 2*time.Minute), false},
+		{"health is never suppressed", event(AlertTypeHealth, SeverityWarning, "feed", StateFiring, 2*time.Minute), true},
+		{"jump resolves", event(AlertTypeJump, SeverityCritical, "up", StateResolved, 5*time.Minute), true},
+		{"trend inside suppression window", event(AlertTypeTrend, SeverityInfo, "up", StateFiring, 6*time.Minute), false},
+		{"jump inside cooldown", event(AlertTypeJump, SeverityCritical, "up", StateFiring, 8*time.Minute), false},
+		{"other jump condition", event(AlertTypeJump, SeverityCritical, "down", StateFiring, 8*time.Minute), true},
+		{"jump down resolves", event(AlertTypeJump, SeverityCritical, "down", StateResolved, 9*time.Minute), true},
+		{"jump after cooldown", event(AlertTypeJump, SeverityCritical, "up", StateFiring, 11*time.Minute), true},
+		{"jump up resolves", event(AlertTypeJump, SeverityCritical, "up", StateResolved, 12*time.Minute), true},
+		{"trend after suppression window", event(AlertTypeTrend, SeverityInfo, "up", StateFiring, 30*time.Minute), true},
+	}
+
+	for _, tt := range tests {
+		if ok, reason := s.Allow(tt.event); ok != tt.allow {
+			t.Errorf("%s: allow=%v (%s), want %v", tt.name, ok, reason, tt.allow)
+		}
+	}
+}
+
+func TestSuppressorPrune(t *testing.T) {
+	cfg := config.DefaultAlertConfig()
+	cfg.Jump.Cooldown = 10 * time.Minute
+	cfg.Trend.SuppressionDuration = 15 * time.Minute
+	s := NewSuppressor(cfg)
+
+	t0 := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	for i, cond := range []string{"up", "down"} {
+		at := t0.Add(time.Duration(i) * time.Minute)
+		fp := Fingerprint(AlertTypeJump, "XAUUSD", cond)
+		s.Allow(&AlertEvent{Type: AlertTypeJump, Severity: SeverityCritical, Symbol: "XAUUSD", Fingerprint: fp, State: StateFiring, Timestamp: at})
+		s.Close(fp)
+	}
+
+	// 冷却期内保留，抑制窗口结束后全部清除
+	s.Prune(t0.Add(10 * time.Minute))
+	if len(s.lastFired) != 1 || len(s.latest) != 1 {
+		t.Errorf("after the first cooldown: %d firings, %d symbols, want 1 and 1", len(s.lastFired), len(s.latest))
+	}
+	s.Prune(t0.Add(16 * time.Minute))
+	if len(s.active) != 0 || len(s.lastFired) != 0 || len(s.latest) != 0 {
+		t.Errorf("after the suppression window: %d active, %d firings, %d symbols, want none", len(s.active), len(s.lastFired), len(s.latest))
+	}
+}
@@ -5,24 +5,53 @@ import (
 	"math"
 	"time"
 
+	"github.com/wangpf09/golddog/pkg/config"
 	"github.com/wangpf09/golddog/pkg/logger"
 	"github.com/wangpf09/golddog/pkg/metrics"
 )
 
+func init() {
+	Register("trend", func(symbol string, cfg *config.AlertConfig) ([]Detector, error) {
+		if !cfg.Trend.Enabled {
+			return nil, nil
+		}
+		return []Detector{NewTrendDetector(symbol, cfg.Trend)}, nil
+	})
+}
+
 type TrendDetector struct {
+	symbol      string
 	emaFast     *metrics.EMA
 	emaSlow     *metrics.EMA
+	minOffset   float64       // Minimum |fast - slow| EMA offset
+	minSlope    float64       // Minimum |slope| of the fast EMA
+	required    int           // Consecutive samples required to trigger
+	minDuration time.Duration // Minimum time the trend must last before it triggers
 	consecutive int
+	since       time.Time // Start of the current run of trend samples
 }
 
-func NewTrendDetector() *TrendDetector {
+// NewTrendDetector creates a new trend detector from the trend config section
+func NewTrendDetector(symbol string, cfg config.TrendConfig) *TrendDetector {
 	return &TrendDetector{
-		emaFast: metrics.NewEMA(0.2),
-		emaSlow: metrics.NewEMA(0.05),
+		symbol:      symbol,
+		emaFast:     metrics.NewEMA(cfg.EMAAlpha),
+		emaSlow:     metrics.NewEMA(cfg.SlowEMAAlpha),
+		minOffset:   cfg.MinOffsetThreshold,
+		minSlope:    cfg.MinSlope,
+		required:    cfg.Consecutive,
+		minDuration: cfg.MinDuration,
 	}
 }
 
-func (t *TrendDetector) Evaluate(price float64) *AlertEvent {
+// Name returns the registry name
+func (t *TrendDetector) Name() string {
+	return "trend"
+}
+
+// Evaluate feeds the latest price into the fast/slow EMAs and alerts on a sustained trend
+func (t *TrendDetector) Evaluate(ctx *Context) *AlertEvent {
+	price := ctx.Snapshot.LastPrice
 	t.emaFast.Update(price)
 	t.emaSlow.Update(price)
 	var fast, slow float64
@@ -35,19 +64,26 @@ func (t *TrendDetector) Evaluate(price float64) *AlertEvent {
 	}
 	diff := fast - slow
 
-	slope := t.emaFast.Slope(12)
+	var slope float64
+	if d, ok := ctx.Changes.Latest(); ok {
+		slope = t.emaFast.Slope(d.Dt)
+	}
 
 	sameDirection := (diff > 0 && slope > 0) || (diff < 0 && slope < 0)
 
-	if math.Abs(diff) >= 3.0 && math.Abs(slope) >= 0.0025 && sameDirection {
+	if math.Abs(diff) >= t.minOffset && math.Abs(slope) >= t.minSlope && sameDirection {
+		if t.consecutive == 0 {
+			t.since = ctx.Now
+		}
 		t.consecutive++
 	} else {
 		t.consecutive = 0
 	}
-	
+
 	logger.Debugf("trend ema fast: %.2f, slow: %.2f, slope: %.2f", fast, slow, slope)
 
-	if t.consecutive >= 5 { // ≈1分钟
+	// 连续样本数与持续时间都达到要求才告警
+	if t.consecutive >= t.required && ctx.Now.Sub(t.since) >= t.minDuration {
 		t.consecutive = 0
 
 		dir := "up"
@@ -57,9 +93,11 @@ func (t *TrendDetector) Evaluate(price float64) *AlertEvent {
 
 		return &AlertEvent{
 			Type:      AlertTypeTrend,
+			Condition: dir,
+			Symbol:    t.symbol,
 			Severity:  SeverityInfo,
 			Message:   fmt.Sprintf("trend %s detected, slope=%.4f USD/s, ema_diff=%.2f", dir, slope, diff),
-			Timestamp: time.Now(),
+			Timestamp: ctx.Now,
 		}
 	}
 	return nil
@@ -0,0 +1,47 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/metrics"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+func TestTrendDetectorMinDuration(t *testing.T) {
+	d := NewTrendDetector("XAUUSD", config.TrendConfig{
+		EMAAlpha:     0.5,
+		SlowEMAAlpha: 0.1,
+		Consecutive:  1,
+		MinDuration:  time.Minute,
+	})
+
+	// 价格每 12 秒上涨 1，趋势持续满 1 分钟才告警
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	changes := metrics.NewRollingWindow[source.Derived](10)
+	var fired []time.Duration
+	for i := range 10 {
+		if i > 0 {
+			changes.Push(source.Derived{PriceChange: 1, Dt: 12 * time.Second})
+		}
+		now := t0.Add(time.Duration(i) * 12 * time.Second)
+		ctx := &Context{
+			Symbol:   "XAUUSD",
+			Snapshot: source.NormalizedSnapshot{LastPrice: 2650 + float64(i), Timestamp: now},
+			Changes:  changes,
+			Now:      now,
+		}
+		if e := d.Evaluate(ctx); e != nil {
+			if e.Condition != "up" {
+				t.Errorf("got a %s trend, want up", e.Condition)
+			}
+			fired = append(fired, now.Sub(t0))
+		}
+	}
+
+	// 12s 起趋势成立，72s 满 1 分钟；告警后重新计时，下一次最早在 144s
+	if len(fired) != 1 || fired[0] != 72*time.Second {
+		t.Errorf("fired at %v, want [1m12s]", fired)
+	}
+}
@@ -2,39 +2,66 @@ package alert
 
 import (
 	"fmt"
-	"time"
 
+	"github.com/wangpf09/golddog/pkg/config"
 	"github.com/wangpf09/golddog/pkg/logger"
 	"github.com/wangpf09/golddog/pkg/metrics"
-	"github.com/wangpf09/golddog/pkg/source"
 )
 
+func init() {
+	Register("volatility", func(symbol string, cfg *config.AlertConfig) ([]Detector, error) {
+		if !cfg.Volatility.Enabled {
+			return nil, nil
+		}
+		return []Detector{NewVolatilityDetector(symbol, cfg.Volatility)}, nil
+	})
+}
+
 type VolatilityDetector struct {
+	symbol      string
+	shortWindow int     // Samples of the short-term stddev
+	longWindow  int     // Samples of the long-term stddev
+	ratio       float64 // Short/long stddev ratio that triggers
+	stdFloor    float64 // Minimum long-term stddev
+	required    int     // Consecutive samples required to trigger
 	consecutive int
 }
 
-// NewVolatilityDetector creates a new jump detector
-func NewVolatilityDetector() *VolatilityDetector {
-	return &VolatilityDetector{}
+// NewVolatilityDetector creates a new volatility detector from the volatility config section
+func NewVolatilityDetector(symbol string, cfg config.VolatilityConfig) *VolatilityDetector {
+	return &VolatilityDetector{
+		symbol:      symbol,
+		shortWindow: cfg.ShortWindow,
+		longWindow:  cfg.LongWindow,
+		ratio:       cfg.Ratio,
+		stdFloor:    cfg.StdFloor,
+		required:    cfg.Consecutive,
+	}
+}
+
+// Name returns the registry name
+func (v *VolatilityDetector) Name() string {
+	return "volatility"
 }
 
 // Evaluate 10min的数据/60min的
-func (v *VolatilityDetector) Evaluate(window *metrics.RollingWindow[source.Derived]) *AlertEvent {
-	if window.Size() < 300 {
+func (v *VolatilityDetector) Evaluate(ctx *Context) *AlertEvent {
+	window := ctx.Changes
+	if window.Size() < max(v.shortWindow, v.longWindow) {
 		return nil
 	}
 
 	values := priceChange(window)
 
-	shortStd := metrics.StdDev(values[len(values)-50:])
-	longStd := metrics.StdDev(values[len(values)-300:])
+	shortStd := metrics.StdDev(values[len(values)-v.shortWindow:])
+	longStd := metrics.StdDev(values[len(values)-v.longWindow:])
 
-	if longStd < 0.2 {
+	if longStd < v.stdFloor {
 		return nil
 	}
 
 	ratio := shortStd / longStd
-	if ratio >= 2.5 {
+	if ratio >= v.ratio {
 		v.consecutive++
 	} else {
 		v.consecutive = 0
@@ -42,13 +69,14 @@ func (v *VolatilityDetector) Evaluate(window *metrics.RollingWindow[source.Deriv
 
 	logger.Debugf("volatility short std: %.2f, long std: %.2f, ratio: %.2f", shortStd, longStd, ratio)
 
-	if v.consecutive >= 3 {
+	if v.consecutive >= v.required {
 		v.consecutive = 0
 		return &AlertEvent{
 			Type:      AlertTypeVolatility,
+			Symbol:    v.symbol,
 			Severity:  SeverityWarning,
 			Message:   fmt.Sprintf("volatility increased: ratio=%.2f", ratio),
-			Timestamp: time.Now(),
+			Timestamp: ctx.Now,
 		}
 	}
 	return nil
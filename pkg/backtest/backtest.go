@@ -0,0 +1,243 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/clock"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/monitor"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+// AlertRecord is an alert fired during a backtest together with its market context
+type AlertRecord struct {
+	Event    *alert.AlertEvent
+	Symbol   string
+	Price    float64 // Last price when the alert fired
+	Move     float64 // Price change over the horizon after the alert
+	MoveRate float64 // Move relative to Price
+	HasMove  bool    // False when the data ends before the horizon
+}
+
+// DetectorStats summarizes the alerts of one alert type on one symbol
+type DetectorStats struct {
+	Symbol      string
+	Type        alert.AlertType
+	Count       int
+	MinGap      time.Duration // Shortest time between two consecutive alerts
+	MeanGap     time.Duration
+	MaxGap      time.Duration
+	MeanAbsMove float64 // Mean |Move| of alerts with a complete horizon
+}
+
+// Report is the outcome of a backtest run
+type Report struct {
+	Snapshots int
+	Start     time.Time
+	End       time.Time
+	Horizon   time.Duration
+	Alerts    []AlertRecord
+	Stats     []DetectorStats
+}
+
+// pricePoint is one observed price used to measure post-alert moves
+type pricePoint struct {
+	t     time.Time
+	price float64
+}
+
+// collector is the monitor sink used offline; it records instead of notifying
+type collector struct {
+	current source.NormalizedSnapshot
+	records []AlertRecord
+}
+
+func (c *collector) Send(e *alert.AlertEvent) error {
+	// 只统计触发，恢复通知与定时报告不计入
+	if e.IsResolved() || e.Type == alert.AlertTypeReport {
+		return nil
+	}
+
+	symbol := e.Symbol
+	if symbol == "" {
+		symbol = c.current.Symbol
+	}
+	c.records = append(c.records, AlertRecord{
+		Event:  e,
+		Symbol: symbol,
+		Price:  c.current.LastPrice,
+	})
+	return nil
+}
+
+func (c *collector) Close() {}
+
+// Load reads all recorded snapshots at path into memory
+func Load(path string) ([]source.NormalizedSnapshot, error) {
+	var snaps []source.NormalizedSnapshot
+	err := source.ReadSnapshots(path, func(snap source.NormalizedSnapshot) error {
+		snaps = append(snaps, snap)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(snaps) == 0 {
+		return nil, fmt.Errorf("no snapshots found at %s", path)
+	}
+	return snaps, nil
+}
+
+// Run loads the recorded snapshots at path and replays them
+func Run(conf *config.Config, path string, horizon time.Duration) (*Report, error) {
+	snaps, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return Replay(conf, snaps, horizon)
+}
+
+// Replay streams snapshots through the monitor pipeline on a virtual clock driven by
+// the snapshot timestamps and reports every alert it would have fired
+func Replay(conf *config.Config, snaps []source.NormalizedSnapshot, horizon time.Duration) (*Report, error) {
+	if len(snaps) == 0 {
+		return nil, fmt.Errorf("no snapshots to replay")
+	}
+
+	clk := clock.NewVirtual(snaps[0].Timestamp)
+	sink := &collector{}
+
+	m, err := monitor.NewMonitor(conf, nil, monitor.WithClock(clk), monitor.WithSink(sink))
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string][]pricePoint)
+	for _, snap := range snaps {
+		clk.Set(snap.Timestamp)
+		sink.current = snap
+		prices[snap.Symbol] = append(prices[snap.Symbol], pricePoint{t: snap.Timestamp, price: snap.LastPrice})
+		m.Feed(snap)
+	}
+
+	report := &Report{
+		Snapshots: len(snaps),
+		Start:     snaps[0].Timestamp,
+		End:       snaps[len(snaps)-1].Timestamp,
+		Horizon:   horizon,
+		Alerts:    sink.records,
+	}
+
+	for i := range report.Alerts {
+		measureMove(&report.Alerts[i], prices[report.Alerts[i].Symbol], horizon)
+	}
+	report.Stats = summarize(report.Alerts)
+
+	return report, nil
+}
+
+// measureMove fills the price move over horizon after the alert
+func measureMove(r *AlertRecord, points []pricePoint, horizon time.Duration) {
+	target := r.Event.Timestamp.Add(horizon)
+	idx := sort.Search(len(points), func(i int) bool {
+		return !points[i].t.Before(target)
+	})
+	if idx == len(points) || r.Price == 0 {
+		return
+	}
+
+	r.Move = points[idx].price - r.Price
+	r.MoveRate = r.Move / r.Price
+	r.HasMove = true
+}
+
+// statsKey groups alerts per symbol, so gaps are measured between alerts of the same symbol
+type statsKey struct {
+	symbol string
+	typ    alert.AlertType
+}
+
+func summarize(records []AlertRecord) []DetectorStats {
+	groups := make(map[statsKey][]AlertRecord)
+	for _, r := range records {
+		key := statsKey{r.Symbol, r.Event.Type}
+		groups[key] = append(groups[key], r)
+	}
+
+	var stats []DetectorStats
+	for key, rs := range groups {
+		st := DetectorStats{Symbol: key.symbol, Type: key.typ, Count: len(rs)}
+
+		var totalGap time.Duration
+		for i := 1; i < len(rs); i++ {
+			gap := rs[i].Event.Timestamp.Sub(rs[i-1].Event.Timestamp)
+			if i == 1 || gap < st.MinGap {
+				st.MinGap = gap
+			}
+			if gap > st.MaxGap {
+				st.MaxGap = gap
+			}
+			totalGap += gap
+		}
+		if len(rs) > 1 {
+			st.MeanGap = totalGap / time.Duration(len(rs)-1)
+		}
+
+		var sumMove float64
+		var moves int
+		for _, r := range rs {
+			if r.HasMove {
+				sumMove += math.Abs(r.Move)
+				moves++
+			}
+		}
+		if moves > 0 {
+			st.MeanAbsMove = sumMove / float64(moves)
+		}
+
+		stats = append(stats, st)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Symbol != stats[j].Symbol {
+			return stats[i].Symbol < stats[j].Symbol
+		}
+		return stats[i].Type < stats[j].Type
+	})
+	return stats
+}
+
+// Write prints every alert followed by the per-detector statistics
+func (r *Report) Write(w io.Writer) error {
+	fmt.Fprintf(w, "Backtest: %d snapshots, %s → %s, horizon %v\n\n",
+		r.Snapshots,
+		r.Start.Format("2006-01-02 15:04:05"),
+		r.End.Format("2006-01-02 15:04:05"),
+		r.Horizon)
+
+	fmt.Fprintf(w, "Alerts (%d):\n", len(r.Alerts))
+	for _, a := range r.Alerts {
+		move := "n/a"
+		if a.HasMove {
+			move = fmt.Sprintf("%+.2f (%+.3f%%)", a.Move, a.MoveRate*100)
+		}
+		fmt.Fprintf(w, "%s | price=%.2f move(%v)=%s\n", a.Event.String(), a.Price, r.Horizon, move)
+	}
+
+	fmt.Fprintln(w, "\nDetector stats:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SYMBOL\tTYPE\tCOUNT\tMIN GAP\tMEAN GAP\tMAX GAP\tMEAN |MOVE|")
+	for _, st := range r.Stats {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%v\t%v\t%v\t%.2f\n",
+			st.Symbol, st.Type, st.Count,
+			st.MinGap.Round(time.Second), st.MeanGap.Round(time.Second), st.MaxGap.Round(time.Second),
+			st.MeanAbsMove)
+	}
+	return tw.Flush()
+}
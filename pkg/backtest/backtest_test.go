@@ -0,0 +1,133 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+// rampSnapshots returns one snapshot per second rising by 0.1 from 2690
+func rampSnapshots(t0 time.Time, n int) []source.NormalizedSnapshot {
+	snaps := make([]source.NormalizedSnapshot, n)
+	for i := range snaps {
+		price := 2690 + float64(i)/10
+		snaps[i] = source.NormalizedSnapshot{
+			Symbol:    "XAUUSD",
+			LastPrice: price,
+			Open:      2690,
+			High:      price,
+			Low:       2690,
+			Volume:    float64(i),
+			Timestamp: t0.Add(time.Duration(i) * time.Second),
+		}
+	}
+	return snaps
+}
+
+// levelOnly enables only the level detector, whose alerts are exactly predictable
+func levelOnly(upper ...float64) *config.Config {
+	alerts := config.DefaultAlertConfig()
+	alerts.Jump.Enabled = false
+	alerts.Trend.Enabled = false
+	alerts.Volatility.Enabled = false
+	alerts.Health.Enabled = false
+	alerts.Level = config.LevelConfig{
+		Enabled: true,
+		Symbols: map[string]config.SymbolLevels{"XAUUSD": {Upper: upper}},
+	}
+	return &config.Config{Alerts: alerts}
+}
+
+func TestReplay(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	// 2700 于 100s、2710 于 200s、2719 于 290s 被突破
+	report, err := Replay(levelOnly(2700, 2710, 2719), rampSnapshots(t0, 300), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Snapshots != 300 || !report.Start.Equal(t0) || !report.End.Equal(t0.Add(299*time.Second)) {
+		t.Fatalf("report covers %d snapshots %v → %v", report.Snapshots, report.Start, report.End)
+	}
+
+	want := []struct {
+		at      time.Duration
+		price   float64
+		move    float64
+		hasMove bool
+	}{
+		{100 * time.Second, 2700, 6, true},
+		{200 * time.Second, 2710, 6, true},
+		{290 * time.Second, 2719, 0, false}, // 数据在观察期结束前终止
+	}
+	if len(report.Alerts) != len(want) {
+		t.Fatalf("got %d alerts, want %d", len(report.Alerts), len(want))
+	}
+	for i, w := range want {
+		a := report.Alerts[i]
+		if a.Event.Type != alert.AlertTypeLevel || a.Symbol != "XAUUSD" {
+			t.Errorf("#%d: got %s alert for %q", i, a.Event.Type, a.Symbol)
+		}
+		if !a.Event.Timestamp.Equal(t0.Add(w.at)) {
+			t.Errorf("#%d fired at %v, want %v", i, a.Event.Timestamp.Sub(t0), w.at)
+		}
+		if !approx(a.Price, w.price) || a.HasMove != w.hasMove || !approx(a.Move, w.move) {
+			t.Errorf("#%d: price %v move %v (%v), want %v %v (%v)", i, a.Price, a.Move, a.HasMove, w.price, w.move, w.hasMove)
+		}
+		if w.hasMove && !approx(a.MoveRate, w.move/w.price) {
+			t.Errorf("#%d: move rate %v, want %v", i, a.MoveRate, w.move/w.price)
+		}
+	}
+
+	if len(report.Stats) != 1 {
+		t.Fatalf("got %d detector stats, want 1", len(report.Stats))
+	}
+	st := report.Stats[0]
+	if st.Type != alert.AlertTypeLevel || st.Count != 3 {
+		t.Errorf("stats %s count %d", st.Type, st.Count)
+	}
+	if st.MinGap != 90*time.Second || st.MaxGap != 100*time.Second || st.MeanGap != 95*time.Second {
+		t.Errorf("gaps min %v mean %v max %v, want 90s 95s 100s", st.MinGap, st.MeanGap, st.MaxGap)
+	}
+	// 只统计有完整观察期的告警
+	if !approx(st.MeanAbsMove, 6) {
+		t.Errorf("mean |move| = %v, want 6", st.MeanAbsMove)
+	}
+}
+
+func TestSummarizePerSymbol(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	// 两个品种的跳变告警交错出现，间隔按品种分别统计
+	var records []AlertRecord
+	for i, at := range []time.Duration{0, 10 * time.Second, time.Minute, 70 * time.Second} {
+		symbol := "XAUUSD"
+		if i%2 == 1 {
+			symbol = "XAGUSD"
+		}
+		records = append(records, AlertRecord{
+			Event:  &alert.AlertEvent{Type: alert.AlertTypeJump, Symbol: symbol, Timestamp: t0.Add(at)},
+			Symbol: symbol,
+		})
+	}
+
+	stats := summarize(records)
+	if len(stats) != 2 {
+		t.Fatalf("got %d stats, want one per symbol", len(stats))
+	}
+	for i, symbol := range []string{"XAGUSD", "XAUUSD"} {
+		st := stats[i]
+		if st.Symbol != symbol || st.Type != alert.AlertTypeJump || st.Count != 2 || st.MinGap != time.Minute || st.MaxGap != time.Minute {
+			t.Errorf("#%d: got %+v, want 2 %s jumps a minute apart", i, st, symbol)
+		}
+	}
+}
+
+func approx(a, b float64) bool {
+	d := a - b
+	return d < 1e-6 && d > -1e-6
+}
@@ -0,0 +1,143 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+)
+
+// Label marks a period in which a real event of the given type happened.
+// An empty Symbol matches any symbol.
+type Label struct {
+	Type   alert.AlertType
+	Symbol string
+	Start  time.Time
+	End    time.Time
+}
+
+// LoadLabels reads labeled events from a CSV file with the columns
+//
+//	type,symbol,start,end
+//
+// where start and end are RFC3339 timestamps. A leading header row is skipped.
+func LoadLabels(path string) ([]Label, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open labels file: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var labels []Label
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "type") {
+			continue
+		}
+
+		start, err := time.Parse(time.RFC3339, record[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid start: %w", path, line, err)
+		}
+		end, err := time.Parse(time.RFC3339, record[3])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid end: %w", path, line, err)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("%s:%d: end before start", path, line)
+		}
+
+		labels = append(labels, Label{
+			Type:   alert.AlertType(record[0]),
+			Symbol: record[1],
+			Start:  start,
+			End:    end,
+		})
+	}
+
+	return labels, nil
+}
+
+// matches reports whether an alert falls into the label period widened by tolerance
+func (l Label) matches(r AlertRecord, tolerance time.Duration) bool {
+	if r.Event.Type != l.Type {
+		return false
+	}
+	if l.Symbol != "" && r.Symbol != l.Symbol {
+		return false
+	}
+	t := r.Event.Timestamp
+	return !t.Before(l.Start.Add(-tolerance)) && !t.After(l.End.Add(tolerance))
+}
+
+// Score is the precision/recall of a set of alerts against labeled events
+type Score struct {
+	Alerts    int // Alerts of the scored type
+	Hits      int // Alerts inside a labeled period
+	Found     int // Labels with at least one alert
+	Labels    int
+	Precision float64
+	Recall    float64
+	F1        float64
+}
+
+// ScoreAlerts scores the alerts of type typ against the labels of the same type
+func ScoreAlerts(records []AlertRecord, labels []Label, typ alert.AlertType, tolerance time.Duration) Score {
+	var s Score
+
+	found := make([]bool, len(labels))
+	for _, l := range labels {
+		if l.Type == typ {
+			s.Labels++
+		}
+	}
+
+	for _, r := range records {
+		if r.Event.Type != typ {
+			continue
+		}
+		s.Alerts++
+
+		hit := false
+		for i, l := range labels {
+			if l.matches(r, tolerance) {
+				found[i] = true
+				hit = true
+			}
+		}
+		if hit {
+			s.Hits++
+		}
+	}
+
+	for _, f := range found {
+		if f {
+			s.Found++
+		}
+	}
+
+	if s.Alerts > 0 {
+		s.Precision = float64(s.Hits) / float64(s.Alerts)
+	}
+	if s.Labels > 0 {
+		s.Recall = float64(s.Found) / float64(s.Labels)
+	}
+	if s.Precision+s.Recall > 0 {
+		s.F1 = 2 * s.Precision * s.Recall / (s.Precision + s.Recall)
+	}
+	return s
+}
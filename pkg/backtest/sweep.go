@@ -0,0 +1,232 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+// SweepSpec describes a grid search over the config section of one detector
+//
+//	detector: jump
+//	tolerance: 2m
+//	params:
+//	  z_threshold: [3.5, 4.0, 4.5]
+//	  std_floor: [0.1, 0.2]
+type SweepSpec struct {
+	Detector  string           `yaml:"detector"`  // jump, trend or volatility
+	Tolerance time.Duration    `yaml:"tolerance"` // Slack around labeled periods when matching alerts
+	Params    map[string][]any `yaml:"params"`    // Config key → candidate values
+}
+
+// SweepResult is the score of one parameter set
+type SweepResult struct {
+	Params map[string]any
+	Score  Score
+}
+
+// sweepTarget maps a detector name to its alert type and config section
+type sweepTarget struct {
+	typ     alert.AlertType
+	section func(a *config.AlertConfig) any
+}
+
+var sweepTargets = map[string]sweepTarget{
+	"jump":       {alert.AlertTypeJump, func(a *config.AlertConfig) any { return &a.Jump }},
+	"trend":      {alert.AlertTypeTrend, func(a *config.AlertConfig) any { return &a.Trend }},
+	"volatility": {alert.AlertTypeVolatility, func(a *config.AlertConfig) any { return &a.Volatility }},
+}
+
+// LoadSweepSpec reads a sweep spec from a YAML file
+func LoadSweepSpec(path string) (*SweepSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sweep spec: %w", err)
+	}
+
+	var spec SweepSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse sweep spec: %w", err)
+	}
+
+	if _, ok := sweepTargets[spec.Detector]; !ok {
+		return nil, fmt.Errorf("unknown sweep detector: %s", spec.Detector)
+	}
+	if len(spec.Params) == 0 {
+		return nil, fmt.Errorf("sweep spec has no params")
+	}
+	for key, values := range spec.Params {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("sweep param %s has no values", key)
+		}
+	}
+
+	return &spec, nil
+}
+
+// Sweep replays snaps once per parameter combination and ranks the combinations
+// by F1 score of the detector's alerts against labels (best first)
+func Sweep(conf *config.Config, snaps []source.NormalizedSnapshot, labels []Label, spec *SweepSpec) ([]SweepResult, error) {
+	target, ok := sweepTargets[spec.Detector]
+	if !ok {
+		return nil, fmt.Errorf("unknown sweep detector: %s", spec.Detector)
+	}
+
+	base := *config.DefaultAlertConfig()
+	if conf.Alerts != nil {
+		base = *conf.Alerts
+	}
+
+	var results []SweepResult
+	for _, params := range expandGrid(spec.Params) {
+		alerts := base
+		if err := applyParams(target.section(&alerts), params); err != nil {
+			return nil, err
+		}
+		if err := alerts.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid sweep params %v: %w", params, err)
+		}
+
+		run := *conf
+		run.Alerts = &alerts
+
+		report, err := Replay(&run, snaps, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, SweepResult{
+			Params: params,
+			Score:  ScoreAlerts(report.Alerts, labels, target.typ, spec.Tolerance),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Score, results[j].Score
+		if a.F1 != b.F1 {
+			return a.F1 > b.F1
+		}
+		if a.Precision != b.Precision {
+			return a.Precision > b.Precision
+		}
+		return a.Recall > b.Recall
+	})
+
+	return results, nil
+}
+
+// expandGrid returns the cartesian product of all parameter values
+func expandGrid(params map[string][]any) []map[string]any {
+	grid := []map[string]any{{}}
+	for _, key := range sortedKeys(params) {
+		var next []map[string]any
+		for _, combo := range grid {
+			for _, v := range params[key] {
+				c := make(map[string]any, len(combo)+1)
+				for k, cv := range combo {
+					c[k] = cv
+				}
+				c[key] = v
+				next = append(next, c)
+			}
+		}
+		grid = next
+	}
+	return grid
+}
+
+// applyParams overrides YAML keys of a config section, reusing the config's own decoding
+func applyParams(section any, params map[string]any) error {
+	data, err := yaml.Marshal(section)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]any)
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	for key, v := range params {
+		if _, ok := fields[key]; !ok {
+			return fmt.Errorf("unknown sweep param: %s", key)
+		}
+		fields[key] = v
+	}
+	// 被扫描的检测器始终启用
+	fields["enabled"] = true
+
+	if data, err = yaml.Marshal(fields); err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, section); err != nil {
+		return fmt.Errorf("invalid sweep params %v: %w", params, err)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sweepRows renders the results as a header plus one row per parameter set
+func sweepRows(spec *SweepSpec, results []SweepResult) [][]string {
+	keys := sortedKeys(spec.Params)
+
+	header := append([]string{"rank"}, keys...)
+	header = append(header, "alerts", "hits", "labels", "found", "precision", "recall", "f1")
+	rows := [][]string{header}
+
+	for i, r := range results {
+		row := []string{fmt.Sprint(i + 1)}
+		for _, k := range keys {
+			row = append(row, fmt.Sprint(r.Params[k]))
+		}
+		s := r.Score
+		row = append(row,
+			fmt.Sprint(s.Alerts), fmt.Sprint(s.Hits), fmt.Sprint(s.Labels), fmt.Sprint(s.Found),
+			fmt.Sprintf("%.3f", s.Precision), fmt.Sprintf("%.3f", s.Recall), fmt.Sprintf("%.3f", s.F1))
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// WriteSweepCSV writes the ranked results as CSV
+func WriteSweepCSV(w io.Writer, spec *SweepSpec, results []SweepResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(sweepRows(spec, results)); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteSweepMarkdown writes the ranked results as a Markdown table
+func WriteSweepMarkdown(w io.Writer, spec *SweepSpec, results []SweepResult) error {
+	rows := sweepRows(spec, results)
+
+	fmt.Fprintf(w, "## %s sweep (tolerance %v)\n\n", spec.Detector, spec.Tolerance)
+	for i, row := range rows {
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | ")); err != nil {
+			return err
+		}
+		if i == 0 {
+			fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(row)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package backtest
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+func record(typ alert.AlertType, symbol, at string) AlertRecord {
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		panic(err)
+	}
+	return AlertRecord{Event: &alert.AlertEvent{Type: typ, Symbol: symbol, Timestamp: t}, Symbol: symbol}
+}
+
+func TestLoadLabels(t *testing.T) {
+	labels, err := LoadLabels("testdata/labels.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 4 {
+		t.Fatalf("got %d labels, want 4", len(labels))
+	}
+	if l := labels[1]; l.Type != alert.AlertTypeJump || l.Symbol != "" || !l.Start.Equal(l.End) {
+		t.Errorf("label 1 = %+v", l)
+	}
+
+	bad := filepath.Join(t.TempDir(), "labels.csv")
+	if err := os.WriteFile(bad, []byte("Jump,XAUUSD,2026-03-02T10:00:00Z,2026-03-02T09:00:00Z\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLabels(bad); err == nil {
+		t.Error("want an error for a label ending before it starts")
+	}
+}
+
+func TestScoreAlerts(t *testing.T) {
+	labels, err := LoadLabels("testdata/labels.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := []AlertRecord{
+		record(alert.AlertTypeJump, "XAUUSD", "2026-03-02T09:11:00Z"),  // 标注期内
+		record(alert.AlertTypeJump, "XAUUSD", "2026-03-02T09:13:30Z"),  // 结束后 1.5 分钟
+		record(alert.AlertTypeJump, "XAGUSD", "2026-03-02T10:01:00Z"),  // 标注不限品种
+		record(alert.AlertTypeJump, "XAUUSD", "2026-03-02T12:01:00Z"),  // 品种不符
+		record(alert.AlertTypeTrend, "XAUUSD", "2026-03-02T11:10:00Z"), // 其他类型不计分
+	}
+
+	tests := []struct {
+		tolerance time.Duration
+		want      Score
+	}{
+		{2 * time.Minute, Score{Alerts: 4, Hits: 3, Found: 2, Labels: 3, Precision: 0.75, Recall: 2.0 / 3, F1: 12.0 / 17}},
+		{0, Score{Alerts: 4, Hits: 1, Found: 1, Labels: 3, Precision: 0.25, Recall: 1.0 / 3, F1: 2.0 / 7}},
+	}
+
+	for _, tt := range tests {
+		got := ScoreAlerts(records, labels, alert.AlertTypeJump, tt.tolerance)
+		if got.Alerts != tt.want.Alerts || got.Hits != tt.want.Hits || got.Found != tt.want.Found || got.Labels != tt.want.Labels {
+			t.Errorf("tolerance %v: counts %+v, want %+v", tt.tolerance, got, tt.want)
+		}
+		for _, p := range [][2]float64{{got.Precision, tt.want.Precision}, {got.Recall, tt.want.Recall}, {got.F1, tt.want.F1}} {
+			if math.Abs(p[0]-p[1]) > 1e-9 {
+				t.Errorf("tolerance %v: got %+v, want %+v", tt.tolerance, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if s := ScoreAlerts(nil, nil, alert.AlertTypeJump, 0); s.Precision != 0 || s.Recall != 0 || s.F1 != 0 {
+		t.Errorf("empty score = %+v", s)
+	}
+}
+
+func TestApplyParams(t *testing.T) {
+	section := config.TrendConfig{EMAAlpha: 0.1, MinOffsetThreshold: 2, Cooldown: time.Minute}
+
+	if err := applyParams(&section, map[string]any{"ema_alpha": 0.2, "cooldown": "30s"}); err != nil {
+		t.Fatal(err)
+	}
+	want := config.TrendConfig{Enabled: true, EMAAlpha: 0.2, MinOffsetThreshold: 2, Cooldown: 30 * time.Second}
+	if section != want {
+		t.Errorf("got %+v, want %+v", section, want)
+	}
+
+	if err := applyParams(&section, map[string]any{"ema_alpah": 0.2}); err == nil {
+		t.Error("want an error for an unknown key")
+	}
+	if err := applyParams(&section, map[string]any{"ema_alpha": "high"}); err == nil {
+		t.Error("want an error for a value of the wrong type")
+	}
+}
+
+func TestExpandGrid(t *testing.T) {
+	grid := expandGrid(map[string][]any{
+		"z_threshold": {3.5, 4.0},
+		"std_floor":   {0.1, 0.2, 0.3},
+	})
+	if len(grid) != 6 {
+		t.Fatalf("got %d combinations, want 6", len(grid))
+	}
+
+	seen := make(map[[2]float64]bool)
+	for _, c := range grid {
+		seen[[2]float64{c["z_threshold"].(float64), c["std_floor"].(float64)}] = true
+	}
+	if len(seen) != 6 {
+		t.Errorf("combinations are not distinct: %v", grid)
+	}
+}
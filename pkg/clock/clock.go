@@ -0,0 +1,53 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock abstracts the current time so the pipeline can run on recorded data
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the wall clock
+type Real struct{}
+
+// Now returns time.Now()
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Virtual is a manually driven clock, typically advanced to each replayed snapshot's timestamp
+type Virtual struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewVirtual creates a virtual clock starting at t
+func NewVirtual(t time.Time) *Virtual {
+	return &Virtual{now: t}
+}
+
+// Now returns the current virtual time
+func (v *Virtual) Now() time.Time {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.now
+}
+
+// Set moves the clock to t; moving backwards is ignored
+func (v *Virtual) Set(t time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if t.After(v.now) {
+		v.now = t
+	}
+}
+
+// Advance moves the clock forward by d
+func (v *Virtual) Advance(d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.now = v.now.Add(d)
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Sampling modes
+const (
+	SamplingLast = "last"
+	SamplingMean = "mean"
+	SamplingVWAP = "vwap"
+)
+
+// DefaultAlertConfig returns the alert settings used for anything missing from the config file
+func DefaultAlertConfig() *AlertConfig {
+	return &AlertConfig{
+		ResolveTimeout: 5 * time.Minute,
+		Sampling: SamplingConfig{
+			Interval: 12 * time.Second,
+			Mode:     SamplingLast,
+			MaxGap:   time.Minute,
+		},
+		Jump: JumpConfig{
+			Enabled:    true,
+			ZThreshold: 4.0,
+			StdFloor:   0.2,
+		},
+		Trend: TrendConfig{
+			Enabled:            true,
+			EMAAlpha:           0.2,
+			SlowEMAAlpha:       0.05,
+			MinOffsetThreshold: 3.0,
+			MinSlope:           0.0025,
+			Consecutive:        5, // ≈1分钟
+		},
+		Volatility: VolatilityConfig{
+			Enabled:     true,
+			ShortWindow: 50,  // 10min
+			LongWindow:  300, // 60min
+			Ratio:       2.5,
+			StdFloor:    0.2,
+			Consecutive: 3,
+		},
+		// 健康检测需显式启用，升级后已有配置不会开始收到健康告警
+		Health: HealthConfig{
+			MaxUnchangedPrice:  300,
+			TimestampTolerance: time.Minute,
+			CheckSuspended:     true,
+		},
+	}
+}
+
+// Validate checks the detector thresholds
+func (c *AlertConfig) Validate() error {
+	if c.ResolveTimeout <= 0 {
+		return fmt.Errorf("alerts.resolve_timeout must be positive")
+	}
+	if err := c.Sampling.validate(); err != nil {
+		return fmt.Errorf("alerts.sampling: %w", err)
+	}
+	if err := c.Jump.validate(); err != nil {
+		return fmt.Errorf("alerts.jump: %w", err)
+	}
+	if err := c.Trend.validate(); err != nil {
+		return fmt.Errorf("alerts.trend: %w", err)
+	}
+	if err := c.Volatility.validate(); err != nil {
+		return fmt.Errorf("alerts.volatility: %w", err)
+	}
+	if err := c.Health.validate(); err != nil {
+		return fmt.Errorf("alerts.health: %w", err)
+	}
+	if err := c.Level.validate(); err != nil {
+		return fmt.Errorf("alerts.level: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("alerts.rules[%d]: %w", i, err)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("alerts.rules[%d]: duplicate rule name %s", i, r.Name)
+		}
+		names[r.Name] = true
+	}
+	return nil
+}
+
+func (c *JumpConfig) validate() error {
+	if c.ZThreshold <= 0 {
+		return fmt.Errorf("z_threshold must be positive")
+	}
+	if c.StdFloor < 0 {
+		return fmt.Errorf("std_floor must not be negative")
+	}
+	if c.Threshold < 0 {
+		return fmt.Errorf("threshold must not be negative")
+	}
+	if c.Cooldown < 0 {
+		return fmt.Errorf("cooldown must not be negative")
+	}
+	return nil
+}
+
+func (c *SamplingConfig) validate() error {
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	switch c.Mode {
+	case "", SamplingLast, SamplingMean, SamplingVWAP:
+	default:
+		return fmt.Errorf("unknown mode %s", c.Mode)
+	}
+	if c.MaxGap < 0 {
+		return fmt.Errorf("max_gap must not be negative")
+	}
+	return nil
+}
+
+func (c *TrendConfig) validate() error {
+	if c.EMAAlpha <= 0 || c.EMAAlpha > 1 {
+		return fmt.Errorf("ema_alpha must be in (0, 1]")
+	}
+	if c.SlowEMAAlpha <= 0 || c.SlowEMAAlpha > 1 {
+		return fmt.Errorf("slow_ema_alpha must be in (0, 1]")
+	}
+	if c.SlowEMAAlpha >= c.EMAAlpha {
+		return fmt.Errorf("slow_ema_alpha must be smaller than ema_alpha")
+	}
+	if c.MinOffsetThreshold < 0 || c.MinSlope < 0 {
+		return fmt.Errorf("min_offset_threshold and min_slope must not be negative")
+	}
+	if c.Consecutive < 1 {
+		return fmt.Errorf("consecutive must be at least 1")
+	}
+	if c.MinDuration < 0 {
+		return fmt.Errorf("min_duration must not be negative")
+	}
+	if c.Cooldown < 0 || c.SuppressionDuration < 0 {
+		return fmt.Errorf("cooldown and suppression_duration must not be negative")
+	}
+	return nil
+}
+
+func (c *VolatilityConfig) validate() error {
+	if c.ShortWindow < 2 {
+		return fmt.Errorf("short_window must be at least 2")
+	}
+	if c.LongWindow <= c.ShortWindow {
+		return fmt.Errorf("long_window must be larger than short_window")
+	}
+	if c.Ratio <= 0 {
+		return fmt.Errorf("ratio must be positive")
+	}
+	if c.StdFloor < 0 {
+		return fmt.Errorf("std_floor must not be negative")
+	}
+	if c.Consecutive < 1 {
+		return fmt.Errorf("consecutive must be at least 1")
+	}
+	return nil
+}
+
+func (c *HealthConfig) validate() error {
+	if c.MaxUnchangedPrice < 0 || c.MaxUnchangedVolume < 0 || c.MaxUnchangedTurnover < 0 {
+		return fmt.Errorf("max_unchanged_* must not be negative")
+	}
+	if c.TimestampTolerance < 0 {
+		return fmt.Errorf("timestamp_tolerance must not be negative")
+	}
+	return nil
+}
+
+// validate checks the static rule fields; expressions are compiled by the rule detector
+func (c *RuleConfig) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if c.Expr == "" {
+		return fmt.Errorf("expr is required")
+	}
+	switch c.Severity {
+	case "", "Info", "Warning", "Critical":
+	default:
+		return fmt.Errorf("unknown severity %s", c.Severity)
+	}
+	return nil
+}
+
+func (c *LevelConfig) validate() error {
+	if c.Hysteresis < 0 {
+		return fmt.Errorf("hysteresis must not be negative")
+	}
+	for symbol, l := range c.Symbols {
+		if l.Unit != "" {
+			if _, _, err := ParsePriceUnit(l.Unit); err != nil {
+				return fmt.Errorf("%s: %w", symbol, err)
+			}
+		}
+		if l.Hysteresis < 0 {
+			return fmt.Errorf("%s: hysteresis must not be negative", symbol)
+		}
+		for _, v := range append(append([]float64{}, l.Upper...), l.Lower...) {
+			if v <= 0 {
+				return fmt.Errorf("%s: levels must be positive", symbol)
+			}
+		}
+	}
+	return nil
+}
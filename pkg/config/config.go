@@ -20,13 +20,64 @@ func LoadConfig(path string) error {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	// 预置默认值，YAML 中未配置的字段保持默认
+	cfg = &Config{Alerts: DefaultAlertConfig()}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	if err := cfg.QOSConfig.validate(); err != nil {
+	switch cfg.Source.GetType() {
+	case "qos":
+		if cfg.QOSConfig == nil {
+			return fmt.Errorf("config validation failed: qos section is required")
+		}
+		if err := cfg.QOSConfig.validate(); err != nil {
+			return fmt.Errorf("config validation failed: %w", err)
+		}
+	case "replay":
+		if cfg.Source.Replay == nil || cfg.Source.Replay.Path == "" {
+			return fmt.Errorf("config validation failed: source.replay.path is required")
+		}
+	}
+
+	if cfg.Alerts == nil {
+		cfg.Alerts = DefaultAlertConfig()
+	}
+	if err := cfg.Alerts.Validate(); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+
+	if cfg.Market != nil {
+		if err := cfg.Market.validate(); err != nil {
+			return fmt.Errorf("config validation failed: %w", err)
+		}
+	}
+	if cfg.Bars != nil {
+		if err := cfg.Bars.validate(); err != nil {
+			return fmt.Errorf("config validation failed: %w", err)
+		}
+	}
+	if cfg.Report != nil {
+		if err := cfg.Report.validate(cfg.Market); err != nil {
+			return fmt.Errorf("config validation failed: %w", err)
+		}
+	}
+
+	if err := cfg.Instruments.validate(); err != nil {
 		return fmt.Errorf("config validation failed: %w", err)
 	}
 
+	if cfg.FX != nil {
+		if err := cfg.FX.validate(); err != nil {
+			return fmt.Errorf("config validation failed: %w", err)
+		}
+	}
+
+	if cfg.Notifier != nil {
+		if err := cfg.Notifier.Validate(); err != nil {
+			return fmt.Errorf("config validation failed: %w", err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultAlertConfig(t *testing.T) {
+	if err := DefaultAlertConfig().Validate(); err != nil {
+		t.Fatalf("default alert config is invalid: %v", err)
+	}
+	if DefaultAlertConfig().Health.Enabled {
+		t.Error("health alerts must be opt-in")
+	}
+
+	tests := []struct {
+		name   string
+		modify func(a *AlertConfig)
+		err    string
+	}{
+		{"resolve timeout", func(a *AlertConfig) { a.ResolveTimeout = 0 }, "resolve_timeout"},
+		{"sampling interval", func(a *AlertConfig) { a.Sampling.Interval = 0 }, "alerts.sampling"},
+		{"sampling mode", func(a *AlertConfig) { a.Sampling.Mode = "median" }, "alerts.sampling"},
+		{"z threshold", func(a *AlertConfig) { a.Jump.ZThreshold = 0 }, "alerts.jump"},
+		{"jump threshold", func(a *AlertConfig) { a.Jump.Threshold = -1 }, "alerts.jump"},
+		{"ema alpha", func(a *AlertConfig) { a.Trend.EMAAlpha = 1.5 }, "alerts.trend"},
+		{"slow ema alpha", func(a *AlertConfig) { a.Trend.SlowEMAAlpha = a.Trend.EMAAlpha }, "alerts.trend"},
+		{"trend consecutive", func(a *AlertConfig) { a.Trend.Consecutive = 0 }, "alerts.trend"},
+		{"trend min duration", func(a *AlertConfig) { a.Trend.MinDuration = -time.Second }, "alerts.trend"},
+		{"volatility windows", func(a *AlertConfig) { a.Volatility.LongWindow = a.Volatility.ShortWindow }, "alerts.volatility"},
+		{"volatility ratio", func(a *AlertConfig) { a.Volatility.Ratio = 0 }, "alerts.volatility"},
+		{"health counts", func(a *AlertConfig) { a.Health.MaxUnchangedPrice = -1 }, "alerts.health"},
+		{"level hysteresis", func(a *AlertConfig) { a.Level.Hysteresis = -1 }, "alerts.level"},
+		{"rule name", func(a *AlertConfig) { a.Rules = []RuleConfig{{Expr: "price > 1"}} }, "alerts.rules[0]"},
+	}
+
+	for _, tt := range tests {
+		a := DefaultAlertConfig()
+		tt.modify(a)
+		err := a.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func loadTestConfig(t *testing.T, data string) (*Config, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(path); err != nil {
+		return nil, err
+	}
+	return GetConfig(), nil
+}
+
+func TestLoadConfigOverlay(t *testing.T) {
+	// 只配置部分字段，其余字段保持默认值
+	conf, err := loadTestConfig(t, `
+source:
+  type: replay
+  replay:
+    path: data/
+alerts:
+  jump:
+    z_threshold: 3.5
+  trend:
+    enabled: false
+  sampling:
+    mode: vwap
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, def := conf.Alerts, DefaultAlertConfig()
+	if a.Jump.ZThreshold != 3.5 || a.Jump.StdFloor != def.Jump.StdFloor || !a.Jump.Enabled || a.Jump.Threshold != 0 {
+		t.Errorf("jump = %+v", a.Jump)
+	}
+	if a.Trend.Enabled || a.Trend.EMAAlpha != def.Trend.EMAAlpha || a.Trend.Consecutive != def.Trend.Consecutive {
+		t.Errorf("trend = %+v", a.Trend)
+	}
+	if a.Sampling.Mode != SamplingVWAP || a.Sampling.Interval != 12*time.Second {
+		t.Errorf("sampling = %+v", a.Sampling)
+	}
+	if a.Volatility != def.Volatility || a.Health != def.Health || a.ResolveTimeout != def.ResolveTimeout {
+		t.Errorf("untouched sections changed: %+v", a)
+	}
+
+	// 没有 alerts 段时使用全部默认值
+	conf, err = loadTestConfig(t, "source:\n  type: replay\n  replay:\n    path: data/\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Alerts.Jump != def.Jump || conf.Alerts.Trend != def.Trend {
+		t.Errorf("alerts = %+v, want defaults", conf.Alerts)
+	}
+
+	// 覆盖后的值仍需通过校验
+	if _, err := loadTestConfig(t, "source:\n  type: replay\n  replay:\n    path: data/\nalerts:\n  trend:\n    slow_ema_alpha: 0.5\n"); err == nil {
+		t.Error("want an error when slow_ema_alpha exceeds the default ema_alpha")
+	}
+}
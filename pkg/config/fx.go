@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// GetPollInterval 获取文件/HTTP 汇率的轮询间隔，默认 1 分钟
+func (c *FXConfig) GetPollInterval() time.Duration {
+	if c.PollInterval <= 0 {
+		return time.Minute
+	}
+	return c.PollInterval
+}
+
+// GetMaxAge 获取实时汇率的最大有效时长，默认 5 分钟
+func (c *FXConfig) GetMaxAge() time.Duration {
+	if c.MaxAge <= 0 {
+		return 5 * time.Minute
+	}
+	return c.MaxAge
+}
+
+func (c *FXConfig) validate() error {
+	if c.Rate < 0 {
+		return fmt.Errorf("fx.rate must not be negative")
+	}
+	if c.File != "" && c.URL != "" {
+		return fmt.Errorf("fx.file and fx.url are mutually exclusive")
+	}
+	if c.Symbol == "" && c.File == "" && c.URL == "" && c.Rate == 0 {
+		return fmt.Errorf("fx requires a symbol, file, url or rate")
+	}
+	if c.PollInterval < 0 || c.MaxAge < 0 {
+		return fmt.Errorf("fx.poll_interval and fx.max_age must not be negative")
+	}
+	return nil
+}
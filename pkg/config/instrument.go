@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Currencies supported by price conversions; USD/CNY uses the fx rate
+const (
+	CurrencyUSD = "USD"
+	CurrencyCNY = "CNY"
+)
+
+// UnitGrams maps the supported quote units to grams
+var UnitGrams = map[string]float64{
+	"g":  1,
+	"kg": 1000,
+	"oz": 31.1034768, // 金衡盎司
+}
+
+// DefaultInstrument is used for symbols without an instruments entry
+var DefaultInstrument = InstrumentConfig{
+	QuoteCurrency: CurrencyUSD,
+	QuoteUnit:     "oz",
+	DisplayUnits:  []string{"CNY/g"},
+}
+
+// ParsePriceUnit splits a price unit such as "CNY/g" into currency and quote unit
+func ParsePriceUnit(s string) (currency, unit string, err error) {
+	currency, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		return "", "", fmt.Errorf("invalid price unit %q, want CURRENCY/UNIT such as CNY/g", s)
+	}
+	if err := checkCurrency(currency); err != nil {
+		return "", "", err
+	}
+	if _, ok := UnitGrams[unit]; !ok {
+		return "", "", fmt.Errorf("unknown unit %s in %s", unit, s)
+	}
+	return currency, unit, nil
+}
+
+func checkCurrency(currency string) error {
+	if currency != CurrencyUSD && currency != CurrencyCNY {
+		return fmt.Errorf("unknown currency %s", currency)
+	}
+	return nil
+}
+
+// Instruments holds the instrument configs keyed by symbol
+type Instruments map[string]InstrumentConfig
+
+// Get 获取品种的报价配置，未配置的字段使用默认值
+func (c Instruments) Get(symbol string) InstrumentConfig {
+	inst, ok := c[symbol]
+	if !ok {
+		return DefaultInstrument
+	}
+	if inst.QuoteCurrency == "" {
+		inst.QuoteCurrency = DefaultInstrument.QuoteCurrency
+	}
+	if inst.QuoteUnit == "" {
+		inst.QuoteUnit = DefaultInstrument.QuoteUnit
+	}
+	if len(inst.DisplayUnits) == 0 {
+		inst.DisplayUnits = DefaultInstrument.DisplayUnits
+	}
+	return inst
+}
+
+// PriceUnit returns the unit of the quoted prices, e.g. USD/oz
+func (c InstrumentConfig) PriceUnit() string {
+	return c.QuoteCurrency + "/" + c.QuoteUnit
+}
+
+// GetMultiplier 获取合约乘数，默认 1
+func (c InstrumentConfig) GetMultiplier() float64 {
+	if c.Multiplier <= 0 {
+		return 1
+	}
+	return c.Multiplier
+}
+
+// Decimals returns the decimals of a quoted price, derived from the tick size (default 2)
+func (c InstrumentConfig) Decimals() int {
+	if c.TickSize <= 0 {
+		return 2
+	}
+	return max(0, int(math.Ceil(-math.Log10(c.TickSize)-1e-9)))
+}
+
+func (c Instruments) validate() error {
+	for symbol, inst := range c {
+		if inst.QuoteCurrency != "" {
+			if err := checkCurrency(inst.QuoteCurrency); err != nil {
+				return fmt.Errorf("instruments.%s.quote_currency: %w", symbol, err)
+			}
+		}
+		if _, ok := UnitGrams[inst.QuoteUnit]; inst.QuoteUnit != "" && !ok {
+			return fmt.Errorf("instruments.%s.quote_unit: unknown unit %s", symbol, inst.QuoteUnit)
+		}
+		for _, u := range inst.DisplayUnits {
+			if _, _, err := ParsePriceUnit(u); err != nil {
+				return fmt.Errorf("instruments.%s.display_units: %w", symbol, err)
+			}
+		}
+		if inst.Multiplier < 0 || inst.TickSize < 0 {
+			return fmt.Errorf("instruments.%s: multiplier and tick_size must not be negative", symbol)
+		}
+	}
+	return nil
+}
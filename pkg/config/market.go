@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// GetLocation 获取市场时区，未配置时使用本地时区
+func (c *MarketConfig) GetLocation() *time.Location {
+	if c == nil {
+		return time.Local
+	}
+	return loadLocation(c.Timezone)
+}
+
+// loadLocation 加载时区，未配置或无效时使用本地时区
+func loadLocation(tz string) *time.Location {
+	if tz == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// GetSessions 获取交易时段，未配置 market 时为空
+func (c *MarketConfig) GetSessions() []SessionConfig {
+	if c == nil {
+		return nil
+	}
+	return c.Sessions
+}
+
+// SessionAt returns the open of the session t falls in. Without sessions the market
+// never closes and SessionAt reports true with a zero open time.
+func (c *MarketConfig) SessionAt(t time.Time) (time.Time, bool) {
+	sessions := c.GetSessions()
+	if len(sessions) == 0 {
+		return time.Time{}, true
+	}
+
+	loc := c.GetLocation()
+	lt := t.In(loc)
+	today := time.Date(lt.Year(), lt.Month(), lt.Day(), 0, 0, 0, 0, loc)
+
+	// 跨夜时段可能从前一天开始
+	for _, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+		if !c.tradingDay(day.Weekday()) {
+			continue
+		}
+		for _, s := range sessions {
+			open, _ := ParseTimeOfDay(s.Open)
+			closing, _ := ParseTimeOfDay(s.Close)
+
+			start, end := AtTimeOfDay(day, open), AtTimeOfDay(day, closing)
+			if !end.After(start) {
+				end = AtTimeOfDay(day.AddDate(0, 0, 1), closing)
+			}
+			if !t.Before(start) && t.Before(end) {
+				return start, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// tradingDay reports whether sessions open on d
+func (c *MarketConfig) tradingDay(d time.Weekday) bool {
+	if len(c.Days) == 0 {
+		return true
+	}
+	for _, name := range c.Days {
+		if Weekdays[name] == d {
+			return true
+		}
+	}
+	return false
+}
+
+// AtTimeOfDay returns the wall-clock time offset from midnight on the date of day, in day's location.
+// Unlike day.Add(offset) it stays on the wall clock across DST changes.
+func AtTimeOfDay(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(),
+		int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// ParseTimeOfDay parses "HH:MM" into the offset from midnight
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, want HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (c *MarketConfig) validate() error {
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("market.timezone: %w", err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, s := range c.Sessions {
+		if s.Name == "" {
+			return fmt.Errorf("market.sessions[%d]: name is required", i)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("market.sessions[%d]: duplicate session %s", i, s.Name)
+		}
+		names[s.Name] = true
+
+		open, err := ParseTimeOfDay(s.Open)
+		if err != nil {
+			return fmt.Errorf("market.sessions[%d].open: %w", i, err)
+		}
+		closing, err := ParseTimeOfDay(s.Close)
+		if err != nil {
+			return fmt.Errorf("market.sessions[%d].close: %w", i, err)
+		}
+		if open == closing {
+			return fmt.Errorf("market.sessions[%d]: open and close must differ", i)
+		}
+	}
+
+	for _, d := range c.Days {
+		if _, ok := Weekdays[d]; !ok {
+			return fmt.Errorf("market.days: unknown day %s, want Mon … Sun", d)
+		}
+	}
+	return nil
+}
+
+// DefaultTimeframes are the bar timeframes built when bars is not configured
+var DefaultTimeframes = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 24 * time.Hour}
+
+// GetTimeframes 获取 K 线周期，未配置时使用默认周期
+func (c *BarsConfig) GetTimeframes() []time.Duration {
+	if c == nil || len(c.Timeframes) == 0 {
+		return DefaultTimeframes
+	}
+	return c.Timeframes
+}
+
+// GetHistory 获取每个周期保留的 K 线数量，默认 500
+func (c *BarsConfig) GetHistory() int {
+	if c == nil || c.History <= 0 {
+		return 500
+	}
+	return c.History
+}
+
+func (c *BarsConfig) validate() error {
+	for _, tf := range c.Timeframes {
+		// 日内周期需整除一天，K 线才能按交易日对齐
+		if tf <= 0 || tf > 24*time.Hour || (24*time.Hour)%tf != 0 {
+			return fmt.Errorf("bars.timeframes: %v must divide 24h", tf)
+		}
+	}
+	if c.History < 0 {
+		return fmt.Errorf("bars.history must not be negative")
+	}
+	return nil
+}
+
+func (c *ReportConfig) validate(market *MarketConfig) error {
+	if c.Daily != "" {
+		if _, err := ParseTimeOfDay(c.Daily); err != nil {
+			return fmt.Errorf("report.daily: %w", err)
+		}
+	}
+	if c.Sessions && len(market.GetSessions()) == 0 {
+		return fmt.Errorf("report.sessions requires market.sessions")
+	}
+	return nil
+}
@@ -8,9 +8,17 @@ import (
 // Config represents the application configuration
 type Config struct {
 	LoggerConfig *LoggerConfig   `yaml:"logger"`
+	Source       *SourceConfig   `yaml:"source"`
 	QOSConfig    *QOSConfig      `yaml:"qos"`
+	Recorder     *RecorderConfig `yaml:"recorder"`
 	Alerts       *AlertConfig    `yaml:"alerts"`
 	Notifier     *NotifierConfig `yaml:"notifier"`
+	Market       *MarketConfig   `yaml:"market"`
+	Report       *ReportConfig   `yaml:"report"`
+	FX           *FXConfig       `yaml:"fx"`
+	Bars         *BarsConfig     `yaml:"bars"`
+
+	Instruments Instruments `yaml:"instruments"`
 }
 
 // LoggerConfig 表示日志配置
@@ -24,11 +32,136 @@ type LoggerConfig struct {
 	Console    bool   `yaml:"console"`
 }
 
+// MarketConfig describes the trading calendar shared by reports, bars and the quote
+// connection supervisor, which does not expect snapshots outside the sessions
+//
+//	market:
+//	  timezone: Asia/Shanghai
+//	  sessions:
+//	    - name: Shanghai
+//	      open: "09:00"
+//	      close: "15:30"
+//	    - name: London
+//	      open: "15:00"
+//	      close: "23:30"
+//	  days: [Mon, Tue, Wed, Thu, Fri]
+type MarketConfig struct {
+	Timezone string          `yaml:"timezone"` // IANA name, default local time
+	Sessions []SessionConfig `yaml:"sessions"`
+	Days     []string        `yaml:"days"` // Days on which the sessions open (Mon … Sun), default every day
+}
+
+// SessionConfig defines a daily trading session, times are HH:MM in the market timezone.
+// A close before the open ends the session on the next day.
+type SessionConfig struct {
+	Name  string `yaml:"name"`
+	Open  string `yaml:"open"`
+	Close string `yaml:"close"`
+}
+
+// BarsConfig defines the OHLCV bars built for every symbol in the market timezone.
+// Intraday bars are cut at the session opens and closes; daily bars start at the open
+// of the first session.
+//
+//	bars:
+//	  timeframes: [1m, 5m, 15m, 1h, 24h]
+//	  history: 500
+type BarsConfig struct {
+	Timeframes []time.Duration `yaml:"timeframes"` // Default 1m, 5m, 15m, 1h and 24h
+	History    int             `yaml:"history"`    // Closed bars kept per timeframe, default 500
+}
+
+// ReportConfig defines scheduled market summary reports
+type ReportConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Hourly   bool   `yaml:"hourly"`
+	Daily    string `yaml:"daily"`    // HH:MM in the market timezone, empty disables
+	Sessions bool   `yaml:"sessions"` // Report at every session open and close
+}
+
+// InstrumentConfig describes how a symbol is quoted and which units its prices are shown in.
+// Symbols without an entry are quoted in USD/oz and shown in CNY/g.
+//
+//	instruments:
+//	  XAGUSD:
+//	    display_units: [CNY/kg]
+//	  AU9999:
+//	    quote_currency: CNY
+//	    quote_unit: g
+//	    display_units: [CNY/g, USD/oz]
+//	  SHFE.au:
+//	    quote_currency: CNY
+//	    quote_unit: g
+//	    multiplier: 1000
+//	    tick_size: 0.02
+type InstrumentConfig struct {
+	QuoteCurrency string   `yaml:"quote_currency"` // USD (default) or CNY
+	QuoteUnit     string   `yaml:"quote_unit"`     // oz (default, troy ounce), g or kg
+	DisplayUnits  []string `yaml:"display_units"`  // Price units shown in reports, default [CNY/g]
+	Multiplier    float64  `yaml:"multiplier"`     // Quote units per contract, default 1
+	TickSize      float64  `yaml:"tick_size"`      // Minimum price increment, sets the shown decimals
+}
+
+// FXConfig defines the USD/CNY rate used to convert USD/oz prices to CNY/g
+//
+// The rate comes from a subscribed FX symbol of the qos stream, or is polled from a local
+// file or HTTP endpoint answering either a bare number or {"rate": 7.18, "time": "..."}.
+// Rate is the static fallback used before the first live rate arrives and while the live
+// rate is older than max_age; a stale live rate also fires a Health alert. Without rate the
+// default 6.92 is used until the first live rate, then the last live rate however old.
+//
+//	fx:
+//	  symbol: USDCNH
+//	  rate: 7.10
+//	  max_age: 5m
+type FXConfig struct {
+	Symbol       string        `yaml:"symbol"`        // FX symbol subscribed on the qos stream, e.g. USDCNH
+	File         string        `yaml:"file"`          // Local file holding the rate
+	URL          string        `yaml:"url"`           // HTTP endpoint returning the rate
+	PollInterval time.Duration `yaml:"poll_interval"` // File/URL poll interval, default 1m
+	Rate         float64       `yaml:"rate"`          // Static fallback rate
+	MaxAge       time.Duration `yaml:"max_age"`       // Live rate staleness limit, default 5m
+}
+
+// SourceConfig selects the snapshot source feeding the monitor
+type SourceConfig struct {
+	Type   string        `yaml:"type"` // qos (default), replay
+	Replay *ReplayConfig `yaml:"replay"`
+}
+
+// ReplayConfig defines configuration for replaying recorded snapshot files
+type ReplayConfig struct {
+	Path  string  `yaml:"path"`  // File, directory or glob pattern of recorded files
+	Speed float64 `yaml:"speed"` // 1 = real time, N = N× speed, 0 = as fast as possible
+}
+
+// RecorderConfig defines configuration for recording live snapshots to disk
+type RecorderConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Dir     string        `yaml:"dir"`
+	Format  string        `yaml:"format"` // jsonl (default) or csv
+	Rotate  time.Duration `yaml:"rotate"` // File rotation interval, default 24h
+	Buffer  int           `yaml:"buffer"`
+}
+
+// GetType 获取数据源类型，未配置时默认为 qos
+func (c *SourceConfig) GetType() string {
+	if c == nil || c.Type == "" {
+		return "qos"
+	}
+	return c.Type
+}
+
 type QOSConfig struct {
 	APIKey        string   `yaml:"api_key"`
 	Symbols       []string `yaml:"symbols"`
 	Heartbeat     int      `yaml:"heartbeat"`
 	ChannelBuffer int      `yaml:"channel_buffer"`
+
+	// IdleTimeout 交易时段内超过该时间未收到任何快照即视为连接断开
+	IdleTimeout             time.Duration `yaml:"idle_timeout"`
+	ReconnectInitialBackoff time.Duration `yaml:"reconnect_initial_backoff"`
+	ReconnectMaxBackoff     time.Duration `yaml:"reconnect_max_backoff"`
 }
 
 // validate checks if the configuration is valid
@@ -49,49 +182,274 @@ func (c *QOSConfig) GetHeartbeatDuration() time.Duration {
 	return time.Duration(c.Heartbeat) * time.Second
 }
 
+// GetIdleTimeout 获取无数据判定断线的时间，默认 3 个心跳周期且不少于 30 秒
+func (c *QOSConfig) GetIdleTimeout() time.Duration {
+	if c.IdleTimeout > 0 {
+		return c.IdleTimeout
+	}
+	return max(3*c.GetHeartbeatDuration(), 30*time.Second)
+}
+
+// GetReconnectBackoff 获取重连退避的初始值与上限
+func (c *QOSConfig) GetReconnectBackoff() (initial, maximum time.Duration) {
+	initial, maximum = c.ReconnectInitialBackoff, c.ReconnectMaxBackoff
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if maximum < initial {
+		maximum = max(time.Minute, initial)
+	}
+	return initial, maximum
+}
+
 // AlertConfig contains alert threshold settings
 type AlertConfig struct {
-	Jump   JumpConfig   `yaml:"jump"`
-	Trend  TrendConfig  `yaml:"trend"`
-	Health HealthConfig `yaml:"health"`
+	ResolveTimeout time.Duration `yaml:"resolve_timeout"` // Quiet period after which an alert resolves, or closes silently when one-shot
+
+	Sampling   SamplingConfig   `yaml:"sampling"`
+	Jump       JumpConfig       `yaml:"jump"`
+	Trend      TrendConfig      `yaml:"trend"`
+	Volatility VolatilityConfig `yaml:"volatility"`
+	Health     HealthConfig     `yaml:"health"`
+	Level      LevelConfig      `yaml:"level"`
+	Rules      []RuleConfig     `yaml:"rules"`
+}
+
+// SamplingConfig defines how raw snapshots are resampled for the detectors. Samples are
+// keyed on the snapshot timestamps, one per interval, so live runs, replays and backtests
+// sample alike. Window sizes and thresholds of the detectors are in samples of this interval.
+type SamplingConfig struct {
+	Interval time.Duration `yaml:"interval"` // Sample spacing, default 12s
+	Mode     string        `yaml:"mode"`     // last (default), mean or vwap of the interval
+	MaxGap   time.Duration `yaml:"max_gap"`  // Longer gaps without data are not filled with flat samples
 }
 
 // JumpConfig defines configuration for Jump detector
 type JumpConfig struct {
-	Enabled    bool          `yaml:"enabled"`
+	Enabled bool `yaml:"enabled"`
+	// Threshold is an extra gate on z-score jumps: the |Δp| of the jump sample must also
+	// reach it, in price units or, with use_percent, in percent of the price.
+	// 0 (default) disables the gate, so jumps are decided by z_threshold alone.
 	Threshold  float64       `yaml:"threshold"`
-	UsePercent bool          `yaml:"use_percent"`
-	Cooldown   time.Duration `yaml:"cooldown"`
+	UsePercent bool          `yaml:"use_percent"` // Threshold is a percentage of the last price
+	Cooldown   time.Duration `yaml:"cooldown"`    // Minimum time before the same jump alert fires again
+	ZThreshold float64       `yaml:"z_threshold"` // z-score of Δp that triggers a jump
+	StdFloor   float64       `yaml:"std_floor"`   // Minimum stddev of Δp, filters quiet markets
 }
 
 // TrendConfig defines configuration for Trend detector
 type TrendConfig struct {
 	Enabled             bool          `yaml:"enabled"`
-	EMAAlpha            float64       `yaml:"ema_alpha"`
-	MinDuration         time.Duration `yaml:"min_duration"`
-	Cooldown            time.Duration `yaml:"cooldown"`
-	SuppressionDuration time.Duration `yaml:"suppression_duration"`
-	MinOffsetThreshold  float64       `yaml:"min_offset_threshold"`
+	EMAAlpha            float64       `yaml:"ema_alpha"` // Fast EMA smoothing factor
+	SlowEMAAlpha        float64       `yaml:"slow_ema_alpha"`
+	MinDuration         time.Duration `yaml:"min_duration"`         // Minimum time the trend must last to trigger
+	Cooldown            time.Duration `yaml:"cooldown"`             // Minimum time before the same trend alert fires again
+	SuppressionDuration time.Duration `yaml:"suppression_duration"` // No trend alert this long after a higher-severity alert
+	MinOffsetThreshold  float64       `yaml:"min_offset_threshold"` // Minimum |fast - slow| EMA offset
+	MinSlope            float64       `yaml:"min_slope"`            // Minimum |slope| of the fast EMA
+	Consecutive         int           `yaml:"consecutive"`          // Consecutive samples required to trigger
 }
 
-// HealthConfig defines configuration for Health detector
+// VolatilityConfig defines configuration for Volatility detector
+type VolatilityConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	ShortWindow int     `yaml:"short_window"` // Samples of the short-term stddev
+	LongWindow  int     `yaml:"long_window"`  // Samples of the long-term stddev
+	Ratio       float64 `yaml:"ratio"`        // Short/long stddev ratio that triggers
+	StdFloor    float64 `yaml:"std_floor"`    // Minimum long-term stddev
+	Consecutive int     `yaml:"consecutive"`  // Consecutive samples required to trigger
+}
+
+// HealthConfig defines configuration for Health detector, disabled by default
+// A zero max_unchanged_* or timestamp_tolerance disables that check.
 type HealthConfig struct {
 	Enabled              bool          `yaml:"enabled"`
-	MaxUnchangedPrice    int           `yaml:"max_unchanged_price"`
-	MaxUnchangedVolume   int           `yaml:"max_unchanged_volume"`
-	MaxUnchangedTurnover int           `yaml:"max_unchanged_turnover"`
-	TimestampTolerance   time.Duration `yaml:"timestamp_tolerance"`
+	MaxUnchangedPrice    int           `yaml:"max_unchanged_price"`    // Ticks with a frozen price
+	MaxUnchangedVolume   int           `yaml:"max_unchanged_volume"`   // Ticks with a frozen volume
+	MaxUnchangedTurnover int           `yaml:"max_unchanged_turnover"` // Ticks with a frozen turnover
+	TimestampTolerance   time.Duration `yaml:"timestamp_tolerance"`    // Max snapshot timestamp drift from the clock
 	CheckSuspended       bool          `yaml:"check_suspended"`
 }
 
+// LevelConfig defines configuration for Level detector
+//
+//	level:
+//	  enabled: true
+//	  hysteresis: 2
+//	  symbols:
+//	    XAUUSD:
+//	      upper: [2700, 2750]
+//	      lower: [2600]
+//	    XAGUSD:
+//	      unit: CNY/g
+//	      upper: [8]
+//	      hysteresis: 0.05
+type LevelConfig struct {
+	Enabled    bool                    `yaml:"enabled"`
+	Hysteresis float64                 `yaml:"hysteresis"` // Re-arm band in the unit of the levels, default 0.02% of the level
+	Symbols    map[string]SymbolLevels `yaml:"symbols"`
+}
+
+// SymbolLevels defines the price levels of one symbol
+type SymbolLevels struct {
+	Unit       string    `yaml:"unit"` // Price unit such as CNY/g, default the quote unit of the symbol
+	Upper      []float64 `yaml:"upper"`
+	Lower      []float64 `yaml:"lower"`
+	Hysteresis float64   `yaml:"hysteresis"` // Overrides the shared band when > 0
+}
+
+// RuleConfig defines a custom alert rule, see package rule for the expression language
+//
+//	rules:
+//	  - name: xau_above_2700
+//	    symbols: [XAUUSD]
+//	    expr: price > 2700
+//	    severity: Warning
+//	    message: "{{.Symbol}} above 2700: {{printf \"%.2f\" .Price}}"
+type RuleConfig struct {
+	Name     string   `yaml:"name"`
+	Enabled  *bool    `yaml:"enabled"` // Defaults to true
+	Symbols  []string `yaml:"symbols"` // Empty means all symbols
+	Expr     string   `yaml:"expr"`
+	Severity string   `yaml:"severity"` // Info, Warning (default) or Critical
+	Message  string   `yaml:"message"`  // text/template over Rule, Symbol, Price, PriceCNY, Time
+}
+
+// IsEnabled reports whether the rule is enabled
+func (c *RuleConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
 // NotifierConfig defines configuration for alert notifier
+//
+// The top-level webhook and delivery settings describe a single "default" channel and
+// serve as defaults for the entries of channels. Routes send each alert to the
+// channels of every matching route; without routes all channels receive every alert.
+//
+//	notifier:
+//	  enabled: true
+//	  channels:
+//	    - name: oncall
+//	      webhook_url: https://open.feishu.cn/open-apis/bot/v2/hook/xxx
+//	      max_retries: 5
+//	    - name: low-noise
+//	      type: slack
+//	      webhook_url: https://hooks.slack.com/services/xxx
+//	      digest:
+//	        window: 15m
+//	        severities: [Info, Warning]
+//	    - name: traders
+//	      type: telegram
+//	      bot_token: "123456:ABC"
+//	      chat_id: "-100123"
+//	  routes:
+//	    - severities: [Critical]
+//	      channels: [oncall]
+//	    - types: [Trend]
+//	      severities: [Info]
+//	      channels: [low-noise]
 type NotifierConfig struct {
 	Enabled        bool          `yaml:"enabled"`
 	WebhookURL     string        `yaml:"webhook_url"`
+	Secret         string        `yaml:"secret"`   // Feishu signing secret of the default channel
+	Keywords       []string      `yaml:"keywords"` // Feishu custom keywords of the default channel
 	MaxRetries     int           `yaml:"max_retries"`
 	InitialBackoff time.Duration `yaml:"initial_backoff"`
-	Backoff        int           `yaml:"backoff"`
+	Backoff        int           `yaml:"backoff"` // Deprecated: seconds, use initial_backoff
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
 	Timeout        time.Duration `yaml:"timeout"`
 	QueueSize      int           `yaml:"queue_size"`
 	Workers        int           `yaml:"workers"`
+
+	// OutboxDir 持久化待投递告警与死信的目录，为空时不持久化
+	OutboxDir string `yaml:"outbox_dir"`
+
+	// Digest is the default digest policy of all channels
+	Digest *DigestConfig `yaml:"digest"`
+
+	Quiet *QuietConfig `yaml:"quiet"`
+
+	Channels []ChannelConfig `yaml:"channels"`
+	Routes   []RouteConfig   `yaml:"routes"`
+}
+
+// ChannelConfig defines a named notification channel with its own queue, workers and retries.
+// Zero values fall back to the top-level notifier settings.
+type ChannelConfig struct {
+	Name       string `yaml:"name"`
+	Type       string `yaml:"type"`        // feishu (default), slack, dingtalk, wecom, telegram
+	WebhookURL string `yaml:"webhook_url"` // For telegram an optional Bot API base URL
+	BotToken   string `yaml:"bot_token"`   // Telegram bot token
+	ChatID     string `yaml:"chat_id"`     // Telegram chat ID
+
+	// Feishu bot security settings
+	Secret   string   `yaml:"secret"`   // Signing secret, adds timestamp and sign to every message
+	Keywords []string `yaml:"keywords"` // Custom keywords, one is added to messages that contain none
+
+	Digest *DigestConfig `yaml:"digest"` // Overrides the notifier digest policy
+
+	MaxRetries     int           `yaml:"max_retries"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	Timeout        time.Duration `yaml:"timeout"`
+	QueueSize      int           `yaml:"queue_size"`
+	Workers        int           `yaml:"workers"`
+}
+
+// DigestConfig batches low-severity alerts of a channel into one message per window.
+// Critical alerts are always sent immediately.
+type DigestConfig struct {
+	Window     time.Duration `yaml:"window"`     // e.g. 15m, counted from the first buffered alert
+	Severities []string      `yaml:"severities"` // Severities to batch, default [Info]
+}
+
+// QuietConfig holds back alerts below a severity during quiet hours and maintenance windows.
+// Held back alerts are muted or deferred to one digest sent when the window ends. Muted alerts
+// are logged and, with outbox_dir, recorded in muted.jsonl.
+//
+//	quiet:
+//	  timezone: Asia/Shanghai
+//	  below: Warning
+//	  action: defer
+//	  hours:
+//	    - start: "00:00"
+//	      end: "07:00"
+//	    - days: [Sat, Sun]
+//	      start: "00:00"
+//	      end: "24:00"
+//	  maintenance:
+//	    - start: 2026-10-20T02:00:00+08:00
+//	      end: 2026-10-20T04:00:00+08:00
+//	      reason: feed provider upgrade
+//	      below: Critical
+type QuietConfig struct {
+	Timezone    string              `yaml:"timezone"` // IANA name, default local time
+	Below       string              `yaml:"below"`    // Alerts below this severity are held back, default Critical
+	Action      string              `yaml:"action"`   // mute (default) or defer
+	Hours       []QuietHours        `yaml:"hours"`
+	Maintenance []MaintenanceWindow `yaml:"maintenance"`
+}
+
+// QuietHours is a recurring daily window, an end before the start wraps past midnight
+type QuietHours struct {
+	Days  []string `yaml:"days"`  // Mon … Sun of the start, empty means every day
+	Start string   `yaml:"start"` // HH:MM
+	End   string   `yaml:"end"`   // HH:MM, 24:00 for the end of the day
+}
+
+// MaintenanceWindow is an ad-hoc window; below and action override the quiet defaults
+type MaintenanceWindow struct {
+	Start  time.Time `yaml:"start"` // RFC 3339
+	End    time.Time `yaml:"end"`
+	Reason string    `yaml:"reason"`
+	Below  string    `yaml:"below"`
+	Action string    `yaml:"action"`
+}
+
+// RouteConfig sends alerts matching all of its non-empty matchers to the listed channels
+type RouteConfig struct {
+	Types      []string `yaml:"types"`      // Alert types, e.g. Jump, Trend
+	Severities []string `yaml:"severities"` // Info, Warning, Critical
+	Symbols    []string `yaml:"symbols"`
+	Channels   []string `yaml:"channels"`
 }
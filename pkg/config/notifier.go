@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultChannel is the name of the channel described by the top-level notifier settings
+const DefaultChannel = "default"
+
+// Notification channel types
+const (
+	ChannelFeishu   = "feishu"
+	ChannelSlack    = "slack"
+	ChannelDingTalk = "dingtalk"
+	ChannelWeCom    = "wecom"
+	ChannelTelegram = "telegram"
+)
+
+// GetType 获取通道类型，未配置时默认为飞书
+func (c *ChannelConfig) GetType() string {
+	if c.Type == "" {
+		return ChannelFeishu
+	}
+	return c.Type
+}
+
+// GetChannels returns the configured channels with defaults applied.
+// Without a channels section the top-level webhook forms the single default channel.
+func (c *NotifierConfig) GetChannels() []ChannelConfig {
+	channels := c.Channels
+	if len(channels) == 0 && c.WebhookURL != "" {
+		channels = []ChannelConfig{{Name: DefaultChannel}}
+	}
+
+	initial := c.InitialBackoff
+	if initial <= 0 && c.Backoff > 0 {
+		initial = time.Duration(c.Backoff) * time.Second
+	}
+
+	resolved := make([]ChannelConfig, len(channels))
+	for i, ch := range channels {
+		if ch.Name == DefaultChannel {
+			if ch.WebhookURL == "" {
+				ch.WebhookURL = c.WebhookURL
+			}
+			if ch.Secret == "" {
+				ch.Secret = c.Secret
+			}
+			if len(ch.Keywords) == 0 {
+				ch.Keywords = c.Keywords
+			}
+		}
+		if ch.MaxRetries <= 0 {
+			ch.MaxRetries = c.MaxRetries
+		}
+		if ch.InitialBackoff <= 0 {
+			ch.InitialBackoff = initial
+		}
+		if ch.MaxBackoff <= 0 {
+			ch.MaxBackoff = c.MaxBackoff
+		}
+		if ch.Timeout <= 0 {
+			ch.Timeout = c.Timeout
+		}
+		if ch.QueueSize <= 0 {
+			ch.QueueSize = c.QueueSize
+		}
+		if ch.Workers <= 0 {
+			ch.Workers = c.Workers
+		}
+		if ch.Digest == nil {
+			ch.Digest = c.Digest
+		}
+		resolved[i] = ch.withDefaults()
+	}
+	return resolved
+}
+
+// withDefaults 未配置的投递参数使用默认值
+func (c ChannelConfig) withDefaults() ChannelConfig {
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = time.Second
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		c.MaxBackoff = max(30*time.Second, c.InitialBackoff)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 100
+	}
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	return c
+}
+
+// Validate checks the channels and that every route points at a known channel
+func (c *NotifierConfig) Validate() error {
+	channels := c.GetChannels()
+	if len(channels) == 0 {
+		return fmt.Errorf("notifier: webhook_url or at least one channel is required")
+	}
+
+	names := make(map[string]bool)
+	for i, ch := range channels {
+		if ch.Name == "" {
+			return fmt.Errorf("notifier.channels[%d]: name is required", i)
+		}
+		if names[ch.Name] {
+			return fmt.Errorf("notifier.channels[%d]: duplicate channel name %s", i, ch.Name)
+		}
+		switch ch.GetType() {
+		case ChannelFeishu, ChannelSlack, ChannelDingTalk, ChannelWeCom:
+			if ch.WebhookURL == "" {
+				return fmt.Errorf("notifier.channels[%d]: webhook_url is required", i)
+			}
+		case ChannelTelegram:
+			if ch.BotToken == "" || ch.ChatID == "" {
+				return fmt.Errorf("notifier.channels[%d]: bot_token and chat_id are required", i)
+			}
+		default:
+			return fmt.Errorf("notifier.channels[%d]: unknown type %s", i, ch.Type)
+		}
+		if ch.GetType() != ChannelFeishu && (ch.Secret != "" || len(ch.Keywords) > 0) {
+			return fmt.Errorf("notifier.channels[%d]: secret and keywords are only supported by feishu", i)
+		}
+		if ch.MaxRetries < 0 {
+			return fmt.Errorf("notifier.channels[%d]: max_retries must not be negative", i)
+		}
+		if err := ch.Digest.validate(); err != nil {
+			return fmt.Errorf("notifier.channels[%d].digest: %w", i, err)
+		}
+		names[ch.Name] = true
+	}
+
+	if c.Quiet != nil {
+		if err := c.Quiet.validate(); err != nil {
+			return fmt.Errorf("notifier.quiet: %w", err)
+		}
+	}
+
+	for i, r := range c.Routes {
+		if len(r.Channels) == 0 {
+			return fmt.Errorf("notifier.routes[%d]: at least one channel is required", i)
+		}
+		for _, name := range r.Channels {
+			if !names[name] {
+				return fmt.Errorf("notifier.routes[%d]: unknown channel %s", i, name)
+			}
+		}
+		for _, s := range r.Severities {
+			switch s {
+			case "Info", "Warning", "Critical":
+			default:
+				return fmt.Errorf("notifier.routes[%d]: unknown severity %s", i, s)
+			}
+		}
+	}
+	return nil
+}
+
+// GetSeverities 获取需要合并发送的告警级别，默认仅 Info
+func (c *DigestConfig) GetSeverities() []string {
+	if len(c.Severities) == 0 {
+		return []string{"Info"}
+	}
+	return c.Severities
+}
+
+func (c *DigestConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("window must be positive")
+	}
+	for _, s := range c.Severities {
+		switch s {
+		case "Info", "Warning":
+		case "Critical":
+			return fmt.Errorf("critical alerts are always sent immediately")
+		default:
+			return fmt.Errorf("unknown severity %s", s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Quiet actions
+const (
+	QuietMute  = "mute"
+	QuietDefer = "defer"
+)
+
+// Weekdays maps the day names used by market days and quiet hours
+var Weekdays = map[string]time.Weekday{
+	"Sun": time.Sunday,
+	"Mon": time.Monday,
+	"Tue": time.Tuesday,
+	"Wed": time.Wednesday,
+	"Thu": time.Thursday,
+	"Fri": time.Friday,
+	"Sat": time.Saturday,
+}
+
+// GetLocation 获取静默时段的时区，未配置时使用本地时区
+func (c *QuietConfig) GetLocation() *time.Location {
+	return loadLocation(c.Timezone)
+}
+
+// GetBelow 获取静默的告警级别上限，默认 Critical（即 Info 与 Warning 被静默）
+func (c *QuietConfig) GetBelow() string {
+	if c.Below == "" {
+		return "Critical"
+	}
+	return c.Below
+}
+
+// GetAction 获取静默动作，默认 mute
+func (c *QuietConfig) GetAction() string {
+	if c.Action == "" {
+		return QuietMute
+	}
+	return c.Action
+}
+
+// ParseQuietEnd parses the end of quiet hours, which also accepts 24:00
+func ParseQuietEnd(s string) (time.Duration, error) {
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	return ParseTimeOfDay(s)
+}
+
+func (c *QuietConfig) validate() error {
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("timezone: %w", err)
+		}
+	}
+	if err := validateQuietPolicy(c.Below, c.Action); err != nil {
+		return err
+	}
+
+	for i, h := range c.Hours {
+		for _, d := range h.Days {
+			if _, ok := Weekdays[d]; !ok {
+				return fmt.Errorf("hours[%d]: unknown day %s, want Mon … Sun", i, d)
+			}
+		}
+		start, err := ParseTimeOfDay(h.Start)
+		if err != nil {
+			return fmt.Errorf("hours[%d].start: %w", i, err)
+		}
+		end, err := ParseQuietEnd(h.End)
+		if err != nil {
+			return fmt.Errorf("hours[%d].end: %w", i, err)
+		}
+		if start == end {
+			return fmt.Errorf("hours[%d]: start and end must differ", i)
+		}
+	}
+
+	for i, m := range c.Maintenance {
+		if m.Start.IsZero() || !m.End.After(m.Start) {
+			return fmt.Errorf("maintenance[%d]: end must be after start", i)
+		}
+		if err := validateQuietPolicy(m.Below, m.Action); err != nil {
+			return fmt.Errorf("maintenance[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateQuietPolicy(below, action string) error {
+	switch below {
+	case "", "Info", "Warning", "Critical":
+	default:
+		return fmt.Errorf("unknown severity %s", below)
+	}
+	switch action {
+	case "", QuietMute, QuietDefer:
+	default:
+		return fmt.Errorf("unknown action %s, want mute or defer", action)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package fx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+// DefaultRate is the USD/CNY rate used when no fx section is configured
+const DefaultRate = 6.92
+
+// SourceStatic marks the configured fallback rate
+const SourceStatic = "static"
+
+// Quote is a USD/CNY rate and when it was observed
+type Quote struct {
+	Rate   float64
+	Time   time.Time
+	Source string // FX symbol, file, URL or "static"
+}
+
+// Rates provides the USD/CNY rate used to convert snapshots. A live rate, either streamed
+// from an FX symbol or polled from a file or URL, is preferred while it is fresh; otherwise
+// the static fallback is used.
+type Rates struct {
+	symbol   string
+	fallback float64
+	maxAge   time.Duration
+	poller   *poller // File/URL poller, nil for a streamed or static rate
+
+	mu    sync.RWMutex
+	live  Quote
+	since time.Time // Start of the live feed, the reference of its age until the first rate
+}
+
+// NewRates creates the rates of the fx config section; nil means DefaultRate only
+func NewRates(cfg *config.FXConfig) *Rates {
+	if cfg == nil {
+		return &Rates{fallback: DefaultRate}
+	}
+
+	r := &Rates{
+		symbol:   cfg.Symbol,
+		fallback: cfg.Rate,
+		maxAge:   cfg.GetMaxAge(),
+		since:    time.Now(),
+	}
+	switch {
+	case cfg.File != "":
+		r.poller = newFilePoller(cfg.File, cfg.GetPollInterval())
+	case cfg.URL != "":
+		r.poller = newHTTPPoller(cfg.URL, cfg.GetPollInterval())
+	}
+	return r
+}
+
+// Symbol returns the streamed FX symbol, empty when the rate is not streamed
+func (r *Rates) Symbol() string {
+	return r.symbol
+}
+
+// Live reports whether the rate comes from a stream, file or URL
+func (r *Rates) Live() bool {
+	return r.symbol != "" || r.poller != nil
+}
+
+// Start begins polling the file or URL until ctx is cancelled or Close is called
+func (r *Rates) Start(ctx context.Context) {
+	r.mu.Lock()
+	r.since = time.Now()
+	r.mu.Unlock()
+
+	if r.poller != nil {
+		go r.poller.run(ctx, r.Update)
+	}
+}
+
+// Close stops the poller
+func (r *Rates) Close() {
+	if r.poller != nil {
+		r.poller.stop()
+	}
+}
+
+// Update records a live rate
+func (r *Rates) Update(q Quote) {
+	if q.Rate <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if q.Time.Before(r.live.Time) {
+		return
+	}
+	r.live = q
+}
+
+// Rate returns the quote to convert with at now: the live rate while it is fresh, else
+// the static fallback. Without a fallback the last live rate is used however old it is,
+// and DefaultRate until the first live rate arrives.
+func (r *Rates) Rate(now time.Time) Quote {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.live.Rate > 0 && (now.Sub(r.live.Time) <= r.maxAge || r.fallback == 0) {
+		return r.live
+	}
+	if r.fallback > 0 {
+		return Quote{Rate: r.fallback, Source: SourceStatic}
+	}
+	return Quote{Rate: DefaultRate, Source: SourceStatic}
+}
+
+// Stale reports how long the live rate has not been updated when that exceeds max_age.
+// The age counts from from at the earliest, e.g. the session open, so a rate that did not
+// update while the market was closed is not stale right after the open.
+func (r *Rates) Stale(now, from time.Time) (time.Duration, bool) {
+	if !r.Live() {
+		return 0, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	last := r.since
+	if r.live.Time.After(last) {
+		last = r.live.Time
+	}
+	if from.After(last) {
+		last = from
+	}
+	age := now.Sub(last)
+	return age, age > r.maxAge
+}
@@ -0,0 +1,109 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+func TestRates(t *testing.T) {
+	r := NewRates(&config.FXConfig{Symbol: "USDCNH", Rate: 7.1, MaxAge: 5 * time.Minute})
+	t0 := time.Now()
+	r.since = t0
+
+	if q := r.Rate(t0); q.Rate != 7.1 || q.Source != SourceStatic {
+		t.Errorf("before the first live rate got %+v, want the fallback", q)
+	}
+
+	r.Update(Quote{Rate: 7.18, Time: t0.Add(time.Minute), Source: "USDCNH"})
+	r.Update(Quote{Rate: 7.3, Time: t0, Source: "USDCNH"}) // 乱序的旧汇率被忽略
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		rate  float64
+		stale bool
+	}{
+		{"fresh", t0.Add(3 * time.Minute), 7.18, false},
+		{"at max age", t0.Add(6 * time.Minute), 7.18, false},
+		{"stale", t0.Add(7 * time.Minute), 7.1, true},
+	}
+	for _, tt := range tests {
+		if q := r.Rate(tt.now); q.Rate != tt.rate {
+			t.Errorf("%s: rate %v, want %v", tt.name, q.Rate, tt.rate)
+		}
+		if _, stale := r.Stale(tt.now, time.Time{}); stale != tt.stale {
+			t.Errorf("%s: stale %v, want %v", tt.name, stale, tt.stale)
+		}
+	}
+
+	// 没有静态汇率时继续使用过期的实时汇率
+	r.fallback = 0
+	if q := r.Rate(t0.Add(time.Hour)); q.Rate != 7.18 {
+		t.Errorf("without fallback got %v, want the last live rate", q.Rate)
+	}
+
+	// 没有静态汇率时，首个实时汇率之前使用默认汇率
+	if q := NewRates(&config.FXConfig{Symbol: "USDCNH"}).Rate(t0); q.Rate != DefaultRate || q.Source != SourceStatic {
+		t.Errorf("without fallback before the first live rate got %+v, want the default rate", q)
+	}
+
+	// 从开盘起计算过期时间
+	if _, stale := r.Stale(t0.Add(time.Hour), t0.Add(56*time.Minute)); stale {
+		t.Error("stale within max_age of the session open")
+	}
+
+	if _, stale := NewRates(nil).Stale(t0.Add(time.Hour), time.Time{}); stale {
+		t.Error("the default rate must never be stale")
+	}
+}
+
+func TestParseQuote(t *testing.T) {
+	at := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		data string
+		rate float64
+		time time.Time
+		err  bool
+	}{
+		{"7.1832\n", 7.1832, at, false},
+		{`{"rate": 7.2}`, 7.2, at, false},
+		{`{"rate": 7.2, "time": "2026-01-05T09:00:00Z"}`, 7.2, at.Add(-time.Hour), false},
+		{`{"rate": 0}`, 0, time.Time{}, true},
+		{"n/a", 0, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		q, err := parseQuote([]byte(tt.data), "test", at)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: err %v, want error %v", tt.data, err, tt.err)
+			continue
+		}
+		if q.Rate != tt.rate || !q.Time.Equal(tt.time) {
+			t.Errorf("%q: got %v at %v, want %v at %v", tt.data, q.Rate, q.Time, tt.rate, tt.time)
+		}
+	}
+}
+
+func TestFilePoller(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usdcny")
+	if err := os.WriteFile(path, []byte("7.25"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRates(&config.FXConfig{File: path, PollInterval: time.Hour})
+	r.Start(context.Background())
+	defer r.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for r.Rate(time.Now()).Rate != 7.25 {
+		if time.Now().After(deadline) {
+			t.Fatalf("rate not read from file, got %+v", r.Rate(time.Now()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
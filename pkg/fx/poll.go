@@ -0,0 +1,133 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/logger"
+)
+
+// poller periodically fetches the rate from a local file or an HTTP endpoint
+type poller struct {
+	name     string
+	interval time.Duration
+	fetch    func(ctx context.Context) (Quote, error)
+
+	done chan struct{}
+	once sync.Once
+}
+
+// newFilePoller reads the rate from path; without a time in the file the quote is
+// timestamped with the modification time, so a file nobody updates goes stale
+func newFilePoller(path string, interval time.Duration) *poller {
+	return &poller{
+		name:     path,
+		interval: interval,
+		done:     make(chan struct{}),
+		fetch: func(context.Context) (Quote, error) {
+			info, err := os.Stat(path)
+			if err != nil {
+				return Quote{}, err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return Quote{}, err
+			}
+			return parseQuote(data, path, info.ModTime())
+		},
+	}
+}
+
+// newHTTPPoller requests the rate from url; without a time in the response the quote
+// is timestamped with the time of the request
+func newHTTPPoller(url string, interval time.Duration) *poller {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &poller{
+		name:     url,
+		interval: interval,
+		done:     make(chan struct{}),
+		fetch: func(ctx context.Context) (Quote, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return Quote{}, err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return Quote{}, err
+			}
+			defer resp.Body.Close()
+
+			data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+			if err != nil {
+				return Quote{}, err
+			}
+			if resp.StatusCode != http.StatusOK {
+				return Quote{}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+			}
+			return parseQuote(data, url, time.Now())
+		},
+	}
+}
+
+func (p *poller) run(ctx context.Context, update func(Quote)) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		q, err := p.fetch(ctx)
+		if err != nil {
+			logger.Warnf("[fx] failed to fetch rate from %s: %v", p.name, err)
+		} else {
+			logger.Debugf("[fx] rate %.4f from %s", q.Rate, p.name)
+			update(q)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *poller) stop() {
+	p.once.Do(func() { close(p.done) })
+}
+
+// parseQuote accepts a bare number or {"rate": 7.18, "time": "2026-01-05T10:00:00+08:00"}
+func parseQuote(data []byte, source string, at time.Time) (Quote, error) {
+	text := strings.TrimSpace(string(data))
+
+	if rate, err := strconv.ParseFloat(text, 64); err == nil {
+		return newQuote(rate, at, source)
+	}
+
+	var body struct {
+		Rate float64   `json:"rate"`
+		Time time.Time `json:"time"`
+	}
+	if err := json.Unmarshal([]byte(text), &body); err != nil {
+		return Quote{}, fmt.Errorf("invalid rate %q: want a number or {\"rate\": ...}", text)
+	}
+	if !body.Time.IsZero() {
+		at = body.Time
+	}
+	return newQuote(body.Rate, at, source)
+}
+
+func newQuote(rate float64, at time.Time, source string) (Quote, error) {
+	if rate <= 0 {
+		return Quote{}, fmt.Errorf("invalid rate %v", rate)
+	}
+	return Quote{Rate: rate, Time: at, Source: source}, nil
+}
@@ -118,14 +118,17 @@ func customTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(t.Format("2006-01-02 15:04:05.000"))
 }
 
-// log 获取全局 logger
+// log 获取全局 logger，未初始化时（如单元测试）丢弃日志
 func log() *zap.Logger {
+	if logr == nil {
+		return zap.NewNop()
+	}
 	return logr
 }
 
 // sugar 获取全局 SugaredLogger
 func sugar() *zap.SugaredLogger {
-	return logr.Sugar()
+	return log().Sugar()
 }
 
 // Sync 刷新日志缓冲区
@@ -0,0 +1,52 @@
+package metrics
+
+import "math"
+
+// ATR is Wilder's Average True Range. The first value is the mean of the first period
+// true ranges, later ones are smoothed as atr = (atr*(period-1) + tr) / period.
+type ATR struct {
+	period    int
+	prevClose float64
+	hasPrev   bool
+	count     int
+	value     float64
+}
+
+// NewATR creates an ATR over period bars, default 14
+func NewATR(period int) *ATR {
+	if period <= 0 {
+		period = 14
+	}
+	return &ATR{period: period}
+}
+
+// Update adds a bar given by its high, low and close. Fed with samples, pass the
+// price three times and the true range becomes |Δp|.
+func (a *ATR) Update(high, low, close float64) {
+	tr := high - low
+	if a.hasPrev {
+		tr = max(tr, math.Abs(high-a.prevClose), math.Abs(low-a.prevClose))
+	}
+	a.prevClose, a.hasPrev = close, true
+
+	n := float64(a.period)
+	if a.count < a.period {
+		a.count++
+		a.value += tr / n
+		return
+	}
+	a.value = (a.value*(n-1) + tr) / n
+}
+
+// UpdateBar adds an OHLCV bar
+func (a *ATR) UpdateBar(b Bar) {
+	a.Update(b.High, b.Low, b.Close)
+}
+
+// Value returns the ATR once period bars have been seen
+func (a *ATR) Value() (float64, bool) {
+	if a.count < a.period {
+		return 0, false
+	}
+	return a.value, true
+}
@@ -0,0 +1,182 @@
+package metrics
+
+import "time"
+
+// Bar is an OHLCV bar of one timeframe
+type Bar struct {
+	Period  time.Duration
+	Start   time.Time
+	End     time.Time // Exclusive; earlier than Start+Period when cut by a session boundary
+	Session string    // Session containing Start, empty outside sessions
+
+	Open, High, Low, Close float64
+	Volume                 float64
+	Ticks                  int
+}
+
+// Range returns High - Low
+func (b Bar) Range() float64 {
+	return b.High - b.Low
+}
+
+// Session is a daily trading session, as offsets from midnight in the bar timezone.
+// A close before the open ends the session on the next day.
+type Session struct {
+	Name        string
+	Open, Close time.Duration
+}
+
+func (s Session) contains(offset time.Duration) bool {
+	if s.Open < s.Close {
+		return offset >= s.Open && offset < s.Close
+	}
+	return offset >= s.Open || offset < s.Close
+}
+
+// BarBuilder aggregates ticks into bars of one period. Intraday bars are aligned to the
+// trading day and cut at session opens and closes; daily bars span the trading day,
+// which starts at the open of the first session, or at midnight without sessions.
+// Bars are driven by the tick timestamps only.
+type BarBuilder struct {
+	period   time.Duration
+	loc      *time.Location
+	sessions []Session
+	dayStart time.Duration // Offset of the trading day start from midnight
+
+	bar    Bar
+	open   bool
+	closed time.Time // End of the last closed bar, later ticks before it are ignored
+	last   time.Time // Latest tick of the open bar, sets the close
+}
+
+// NewBarBuilder creates a builder of bars of period in loc
+func NewBarBuilder(period time.Duration, loc *time.Location, sessions []Session) *BarBuilder {
+	b := &BarBuilder{period: period, loc: loc, sessions: sessions}
+	if len(sessions) > 0 {
+		b.dayStart = sessions[0].Open
+	}
+	return b
+}
+
+// Period returns the bar period
+func (b *BarBuilder) Period() time.Duration {
+	return b.period
+}
+
+// Add folds a tick into the open bar. It returns the previous bar when the tick starts a new one;
+// ticks older than the open bar are ignored.
+func (b *BarBuilder) Add(t time.Time, price, volume float64) (Bar, bool) {
+	if (b.open && t.Before(b.bar.Start)) || t.Before(b.closed) {
+		return Bar{}, false
+	}
+
+	var done Bar
+	var closed bool
+	if b.open && !t.Before(b.bar.End) {
+		done, closed = b.bar, true
+		b.open, b.closed = false, b.bar.End
+	}
+
+	if !b.open {
+		start, end := b.span(t)
+		b.bar = Bar{
+			Period:  b.period,
+			Start:   start,
+			End:     end,
+			Session: b.session(start),
+			Open:    price,
+			High:    price,
+			Low:     price,
+		}
+		b.open = true
+	}
+
+	b.bar.High = max(b.bar.High, price)
+	b.bar.Low = min(b.bar.Low, price)
+	if !t.Before(b.last) {
+		b.bar.Close, b.last = price, t
+	}
+	b.bar.Volume += volume
+	b.bar.Ticks++
+	return done, closed
+}
+
+// Due returns the open bar once now has reached its end, e.g. after a session close
+// when no further tick arrives
+func (b *BarBuilder) Due(now time.Time) (Bar, bool) {
+	if !b.open || now.Before(b.bar.End) {
+		return Bar{}, false
+	}
+	b.open, b.closed = false, b.bar.End
+	return b.bar, true
+}
+
+// span returns the bounds of the bar containing t. Bar bounds are wall-clock times in
+// the bar timezone, so they stay aligned across DST changes.
+func (b *BarBuilder) span(t time.Time) (time.Time, time.Time) {
+	local := t.In(b.loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, b.loc)
+
+	day := b.at(midnight, b.dayStart)
+	if local.Before(day) {
+		day = b.at(midnight.AddDate(0, 0, -1), b.dayStart)
+	}
+	nextDay := day.AddDate(0, 0, 1)
+
+	if b.period >= 24*time.Hour {
+		return day, nextDay
+	}
+
+	offset := wall(local).Sub(wall(day)).Truncate(b.period)
+	start, end := b.at(day, offset), b.at(day, offset+b.period)
+	if start.After(t) || !end.After(t) {
+		// 落在夏令时切换跳过的时段，按实际时长计算
+		start = day.Add(t.Sub(day).Truncate(b.period))
+		end = start.Add(b.period)
+	}
+	if end.After(nextDay) {
+		end = nextDay
+	}
+
+	// 日内 K 线不跨越交易时段的开收盘
+	for _, d := range []time.Time{day.AddDate(0, 0, -1), day, nextDay} {
+		dayMidnight := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, b.loc)
+		for _, s := range b.sessions {
+			for _, o := range []time.Duration{s.Open, s.Close} {
+				at := b.at(dayMidnight, o)
+				if !at.After(start) || !at.Before(end) {
+					continue
+				}
+				if t.Before(at) {
+					end = at
+				} else {
+					start = at
+				}
+			}
+		}
+	}
+	return start, end
+}
+
+// at returns the time whose wall clock is offset after the wall clock of t, in the bar timezone
+func (b *BarBuilder) at(t time.Time, offset time.Duration) time.Time {
+	w := wall(t.In(b.loc)).Add(offset)
+	return time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), w.Nanosecond(), b.loc)
+}
+
+// wall returns the wall clock of t as a UTC time, for arithmetic without DST changes
+func wall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// session returns the name of the first session containing t
+func (b *BarBuilder) session(t time.Time) string {
+	local := t.In(b.loc)
+	offset := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+	for _, s := range b.sessions {
+		if s.contains(offset) {
+			return s.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBarBuilderSessions(t *testing.T) {
+	sessions := []Session{
+		{Name: "Shanghai", Open: 9 * time.Hour, Close: 15*time.Hour + 30*time.Minute},
+		{Name: "London", Open: 15 * time.Hour, Close: 23*time.Hour + 30*time.Minute},
+	}
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+
+	b := NewBarBuilder(time.Hour, time.UTC, sessions)
+	var bars []Bar
+	add := func(t time.Time, price, volume float64) {
+		if bar, ok := b.Add(t, price, volume); ok {
+			bars = append(bars, bar)
+		}
+	}
+
+	add(at(14, 10), 100, 1)
+	add(at(14, 50), 105, 2)
+	add(at(14, 20), 90, 5) // 乱序，但仍在当前 K 线内
+	add(at(15, 10), 98, 1)
+	add(at(15, 40), 99, 1)
+	add(at(15, 20), 80, 1) // 已收盘的 K 线，忽略
+	if bar, ok := b.Due(at(16, 0)); ok {
+		bars = append(bars, bar)
+	}
+
+	want := []string{
+		"14:00-15:00 Shanghai O100 H105 L90 C105 V8",
+		"15:00-15:30 Shanghai O98 H98 L98 C98 V1",
+		"15:30-16:00 London O99 H99 L99 C99 V1",
+	}
+	if len(bars) != len(want) {
+		t.Fatalf("got %d bars, want %d: %+v", len(bars), len(want), bars)
+	}
+	for i, bar := range bars {
+		got := fmt.Sprintf("%s-%s %s O%g H%g L%g C%g V%g", bar.Start.Format("15:04"), bar.End.Format("15:04"),
+			bar.Session, bar.Open, bar.High, bar.Low, bar.Close, bar.Volume)
+		if got != want[i] {
+			t.Errorf("bar %d: got %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestBarBuilderTradingDay(t *testing.T) {
+	sessions := []Session{{Name: "Night", Open: 20 * time.Hour, Close: 2*time.Hour + 30*time.Minute}, {Name: "Day", Open: 9 * time.Hour, Close: 15 * time.Hour}}
+	b := NewBarBuilder(24*time.Hour, time.UTC, sessions)
+
+	// 交易日从第一个时段（夜盘 20:00）开始
+	b.Add(time.Date(2026, 1, 5, 21, 0, 0, 0, time.UTC), 600, 1)
+	b.Add(time.Date(2026, 1, 6, 10, 0, 0, 0, time.UTC), 610, 1)
+	bar, ok := b.Add(time.Date(2026, 1, 6, 20, 30, 0, 0, time.UTC), 605, 1)
+
+	if !ok {
+		t.Fatal("expected the trading day to close at 20:00")
+	}
+	if !bar.Start.Equal(time.Date(2026, 1, 5, 20, 0, 0, 0, time.UTC)) || !bar.End.Equal(time.Date(2026, 1, 6, 20, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily bar spans %v - %v", bar.Start, bar.End)
+	}
+	if bar.Open != 600 || bar.Close != 610 || bar.Ticks != 2 || bar.Session != "Night" {
+		t.Errorf("unexpected daily bar %+v", bar)
+	}
+}
+
+func TestBarBuilderDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	at := func(month, day, h, m int) time.Time { return time.Date(2026, time.Month(month), day, h, m, 0, 0, loc) }
+
+	tests := []struct {
+		name       string
+		period     time.Duration
+		sessions   []Session
+		tick       time.Time
+		start, end time.Time
+	}{
+		// 2026-03-08 夏令时开始，当天 23 小时；2026-11-01 夏令时结束，当天 25 小时
+		{"4h after spring forward", 4 * time.Hour, nil, at(3, 8, 5, 0), at(3, 8, 4, 0), at(3, 8, 8, 0)},
+		{"4h after fall back", 4 * time.Hour, nil, at(11, 1, 13, 0), at(11, 1, 12, 0), at(11, 1, 16, 0)},
+		{"session close after fall back", time.Hour, []Session{{Name: "NY", Open: 18 * time.Hour, Close: 16*time.Hour + 30*time.Minute}},
+			at(11, 1, 16, 10), at(11, 1, 16, 0), at(11, 1, 16, 30)},
+		{"daily after fall back", 24 * time.Hour, []Session{{Name: "NY", Open: 18 * time.Hour, Close: 17 * time.Hour}},
+			at(11, 1, 12, 0), at(10, 31, 18, 0), at(11, 1, 18, 0)},
+	}
+	for _, tt := range tests {
+		b := NewBarBuilder(tt.period, loc, tt.sessions)
+		b.Add(tt.tick, 100, 1)
+		if !b.bar.Start.Equal(tt.start) || !b.bar.End.Equal(tt.end) {
+			t.Errorf("%s: bar %v - %v, want %v - %v", tt.name, b.bar.Start, b.bar.End, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import "math"
+
+// Bollinger are Bollinger Bands: the simple moving average of period values and bands
+// k population standard deviations above and below it
+type Bollinger struct {
+	window *RollingWindow[float64]
+	k      float64
+	sum    float64
+	sumSq  float64
+}
+
+// NewBollinger creates Bollinger Bands, classically period 20 and k 2
+func NewBollinger(period int, k float64) *Bollinger {
+	return &Bollinger{window: NewRollingWindow[float64](period), k: k}
+}
+
+// Update adds a value
+func (b *Bollinger) Update(value float64) {
+	if b.window.IsFull() {
+		old, _ := b.window.Oldest()
+		b.sum -= old
+		b.sumSq -= old * old
+	}
+	b.window.Push(value)
+	b.sum += value
+	b.sumSq += value * value
+}
+
+// Value returns the middle band once the window is full
+func (b *Bollinger) Value() (float64, bool) {
+	if !b.window.IsFull() {
+		return 0, false
+	}
+	return b.sum / float64(b.window.Size()), true
+}
+
+// StdDev returns the population standard deviation of the window
+func (b *Bollinger) StdDev() (float64, bool) {
+	mean, ok := b.Value()
+	if !ok {
+		return 0, false
+	}
+	// 滑动求和有舍入误差，方差可能略小于 0
+	return math.Sqrt(max(b.sumSq/float64(b.window.Size())-mean*mean, 0)), true
+}
+
+// Bands returns the lower, middle and upper bands
+func (b *Bollinger) Bands() (lower, middle, upper float64, ok bool) {
+	std, ok := b.StdDev()
+	if !ok {
+		return 0, 0, 0, false
+	}
+	middle, _ = b.Value()
+	return middle - b.k*std, middle, middle + b.k*std, true
+}
+
+// PercentB returns where value lies within the bands, 0 at the lower and 1 at the upper band
+func (b *Bollinger) PercentB(value float64) (float64, bool) {
+	lower, _, upper, ok := b.Bands()
+	if !ok || upper == lower {
+		return 0, false
+	}
+	return (value - lower) / (upper - lower), true
+}
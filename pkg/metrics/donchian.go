@@ -0,0 +1,66 @@
+package metrics
+
+// Donchian is the Donchian channel: the highest high and lowest low of the last period bars.
+// Monotonic queues keep each update amortized O(1).
+type Donchian struct {
+	period int
+	n      int // Bars seen
+	highs  []indexed
+	lows   []indexed
+}
+
+type indexed struct {
+	i     int
+	value float64
+}
+
+// NewDonchian creates a Donchian channel over period bars, default 20
+func NewDonchian(period int) *Donchian {
+	if period <= 0 {
+		period = 20
+	}
+	return &Donchian{period: period}
+}
+
+// Update adds a bar given by its high and low; pass the price twice for samples
+func (d *Donchian) Update(high, low float64) {
+	i := d.n
+	d.n++
+
+	for len(d.highs) > 0 && d.highs[len(d.highs)-1].value <= high {
+		d.highs = d.highs[:len(d.highs)-1]
+	}
+	d.highs = append(d.highs, indexed{i, high})
+	for len(d.lows) > 0 && d.lows[len(d.lows)-1].value >= low {
+		d.lows = d.lows[:len(d.lows)-1]
+	}
+	d.lows = append(d.lows, indexed{i, low})
+
+	// 移除窗口之外的极值
+	oldest := i - d.period + 1
+	if d.highs[0].i < oldest {
+		d.highs = d.highs[1:]
+	}
+	if d.lows[0].i < oldest {
+		d.lows = d.lows[1:]
+	}
+}
+
+// UpdateBar adds an OHLCV bar
+func (d *Donchian) UpdateBar(b Bar) {
+	d.Update(b.High, b.Low)
+}
+
+// Value returns the middle of the channel once period bars have been seen
+func (d *Donchian) Value() (float64, bool) {
+	lower, upper, ok := d.Channel()
+	return (lower + upper) / 2, ok
+}
+
+// Channel returns the lowest low and highest high of the window
+func (d *Donchian) Channel() (lower, upper float64, ok bool) {
+	if d.n < d.period {
+		return 0, 0, false
+	}
+	return d.lows[0].value, d.highs[0].value, true
+}
@@ -1,5 +1,7 @@
 package metrics
 
+import "time"
+
 // EMA represents an Exponential Moving Average calculator
 type EMA struct {
 	alpha       float64
@@ -67,9 +69,10 @@ func (e *EMA) PreValue() float64 {
 	return e.preValue
 }
 
-func (e *EMA) Slope(timeStep int64) float64 {
-	if e.preValue == 0 {
+// Slope returns the change of the last update per second, dt being the time between updates
+func (e *EMA) Slope(dt time.Duration) float64 {
+	if e.preValue == 0 || dt <= 0 {
 		return 0
 	}
-	return (e.value - e.preValue) / float64(timeStep)
+	return (e.value - e.preValue) / dt.Seconds()
 }
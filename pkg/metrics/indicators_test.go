@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// closes 与 rsi14 取自 StockCharts ChartSchool 的 RSI 示例表格 (cs-rsi.xls)，
+// bars 与 atr14 取自其 ATR 示例表格 (cs-atr.xls)
+var closes = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+	46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57,
+	43.42, 42.66, 43.13,
+}
+
+// rsi14 是 closes[14:] 对应的 RSI(14)
+var rsi14 = []float64{
+	70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+	54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.09, 37.79,
+}
+
+var bars = []Bar{
+	{High: 48.70, Low: 47.79, Close: 48.16}, {High: 48.72, Low: 48.14, Close: 48.61},
+	{High: 48.90, Low: 48.39, Close: 48.75}, {High: 48.87, Low: 48.37, Close: 48.63},
+	{High: 48.82, Low: 48.24, Close: 48.74}, {High: 49.05, Low: 48.64, Close: 49.03},
+	{High: 49.20, Low: 48.94, Close: 49.07}, {High: 49.35, Low: 48.86, Close: 49.32},
+	{High: 49.92, Low: 49.50, Close: 49.91}, {High: 50.19, Low: 49.87, Close: 50.13},
+	{High: 50.12, Low: 49.20, Close: 49.53}, {High: 49.66, Low: 48.90, Close: 49.50},
+	{High: 49.88, Low: 49.43, Close: 49.75}, {High: 50.19, Low: 49.73, Close: 50.03},
+	{High: 50.36, Low: 49.26, Close: 50.31}, {High: 50.57, Low: 50.09, Close: 50.52},
+	{High: 50.65, Low: 50.30, Close: 50.41}, {High: 50.43, Low: 49.21, Close: 49.34},
+	{High: 49.63, Low: 48.98, Close: 49.37}, {High: 50.33, Low: 49.61, Close: 50.23},
+	{High: 50.29, Low: 49.20, Close: 49.24}, {High: 50.17, Low: 49.43, Close: 49.93},
+	{High: 49.32, Low: 48.08, Close: 48.43}, {High: 48.50, Low: 47.64, Close: 48.18},
+	{High: 48.32, Low: 41.55, Close: 46.57}, {High: 46.80, Low: 44.28, Close: 45.41},
+	{High: 47.80, Low: 47.31, Close: 47.77}, {High: 48.39, Low: 47.20, Close: 47.72},
+	{High: 48.66, Low: 47.90, Close: 48.62}, {High: 48.79, Low: 47.73, Close: 47.85},
+}
+
+// atr14 是 bars[13:] 对应的 ATR(14)
+var atr14 = []float64{
+	0.56, 0.59, 0.59, 0.57, 0.62, 0.62, 0.64, 0.67, 0.69, 0.78,
+	0.78, 1.21, 1.30, 1.38, 1.37, 1.34, 1.32,
+}
+
+// TestIndicatorsReference checks RSI and ATR against the published StockCharts tables.
+// The tables round intermediate values to two decimals, e.g. the first average gain and
+// loss of the RSI, so the tolerances cover that rounding.
+func TestIndicatorsReference(t *testing.T) {
+	rsi := NewRSI(14)
+	for i, c := range closes {
+		rsi.Update(c)
+		if i < 14 {
+			continue
+		}
+		if got, ok := rsi.Value(); !ok || math.Abs(got-rsi14[i-14]) > 0.1 {
+			t.Errorf("RSI(14) at close %d = %.2f (%v), want %.2f", i, got, ok, rsi14[i-14])
+		}
+	}
+
+	atr := NewATR(14)
+	for i, b := range bars {
+		atr.UpdateBar(b)
+		if i < 13 {
+			continue
+		}
+		if got, ok := atr.Value(); !ok || math.Abs(got-atr14[i-13]) > 0.01 {
+			t.Errorf("ATR(14) at bar %d = %.4f (%v), want %.2f", i, got, ok, atr14[i-13])
+		}
+	}
+}
+
+func TestIndicators(t *testing.T) {
+	feed := func(update func(float64)) {
+		for _, c := range closes {
+			update(c)
+		}
+	}
+	feedBars := func(update func(Bar)) {
+		for _, b := range bars {
+			update(b)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		value func() (float64, bool)
+		want  float64
+	}{
+		{"Bollinger(5,2) middle", func() (float64, bool) { b := NewBollinger(5, 2); feed(b.Update); return b.Value() },
+			(44.22 + 44.57 + 43.42 + 42.66 + 43.13) / 5},
+		{"ROC(10)", func() (float64, bool) { r := NewROC(10); feed(r.Update); return r.Value() }, (43.13/45.71 - 1) * 100},
+		{"Donchian(5) middle", func() (float64, bool) { d := NewDonchian(5); feedBars(d.UpdateBar); return d.Value() }, (44.28 + 48.79) / 2},
+		{"VWAP", func() (float64, bool) {
+			v := NewVWAP()
+			for i, vol := range []float64{100, 200, 150} {
+				b := bars[i]
+				b.Volume = vol
+				v.UpdateBar(b)
+			}
+			return v.Value()
+		}, ((48.70+47.79+48.16)/3*100 + (48.72+48.14+48.61)/3*200 + (48.90+48.39+48.75)/3*150) / 450},
+		{"LinReg(5) line", func() (float64, bool) {
+			l := NewLinReg(5)
+			for x := range 9 {
+				l.Update(2*float64(x) + 1)
+			}
+			return l.Value()
+		}, 2},
+		{"LinReg(5) line R²", func() (float64, bool) {
+			l := NewLinReg(5)
+			for x := range 9 {
+				l.Update(2*float64(x) + 1)
+			}
+			return l.R2()
+		}, 1},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.value()
+		if !ok {
+			t.Errorf("%s: not ready", tt.name)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %.6f, want %.6f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIndicatorsWarmUp(t *testing.T) {
+	type indicator struct {
+		update func(float64)
+		value  func() (float64, bool)
+	}
+
+	tests := []struct {
+		name   string
+		new    func() indicator
+		warmUp int // Values needed before Value is ready
+	}{
+		{"RSI(14)", func() indicator { r := NewRSI(14); return indicator{r.Update, r.Value} }, 15},
+		{"MACD(12,26,9)", func() indicator { m := NewMACD(12, 26, 9); return indicator{m.Update, m.Value} }, 26},
+		{"MACD(12,26,9) signal", func() indicator { m := NewMACD(12, 26, 9); return indicator{m.Update, m.Signal} }, 34},
+		{"Bollinger(20,2)", func() indicator { b := NewBollinger(20, 2); return indicator{b.Update, b.Value} }, 20},
+		{"ROC(10)", func() indicator { r := NewROC(10); return indicator{r.Update, r.Value} }, 11},
+		{"LinReg(8)", func() indicator { l := NewLinReg(8); return indicator{l.Update, l.Value} }, 8},
+	}
+
+	for _, tt := range tests {
+		ind := tt.new()
+		for i := 1; i <= tt.warmUp; i++ {
+			ind.update(100 + float64(i%3))
+			if _, ok := ind.value(); ok != (i == tt.warmUp) {
+				t.Errorf("%s: ready=%v after %d values", tt.name, ok, i)
+			}
+		}
+	}
+}
+
+// ema recomputes the EMA of values seeded with the first value as a weighted sum:
+// Σ α(1-α)^k·x[n-1-k] plus (1-α)^(n-1)·x[0]
+func ema(values []float64, period int) float64 {
+	alpha := 2 / float64(period+1)
+	var sum float64
+	weight := 1.0 // (1-α)^k
+	for k := 0; k < len(values)-1; k++ {
+		sum += alpha * weight * values[len(values)-1-k]
+		weight *= 1 - alpha
+	}
+	return sum + weight*values[0]
+}
+
+// TestIndicatorsMatchFullWindow checks the O(1) sliding and recursive updates against a full recomputation
+func TestIndicatorsMatchFullWindow(t *testing.T) {
+	const period = 30
+	rng := rand.New(rand.NewSource(1))
+
+	boll, lr, don := NewBollinger(period, 2), NewLinReg(period), NewDonchian(period)
+	macd := NewMACD(12, 26, 9)
+	var lines []float64
+	var values []float64
+	price := 2650.0
+	for i := range 5000 {
+		price += rng.NormFloat64()
+		values = append(values, price)
+		boll.Update(price)
+		lr.Update(price)
+		don.Update(price, price)
+		macd.Update(price)
+		if i >= 25 {
+			lines = append(lines, ema(values, 12)-ema(values, 26))
+		}
+
+		if i < period-1 || i%97 != 0 {
+			continue
+		}
+		w := values[len(values)-period:]
+
+		std, _ := boll.StdDev()
+		if want := StdDev(w); math.Abs(std-want) > 1e-6 {
+			t.Fatalf("step %d: Bollinger std %v, want %v", i, std, want)
+		}
+
+		lower, upper, _ := don.Channel()
+		lo, hi := w[0], w[0]
+		for _, v := range w {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+		if lower != lo || upper != hi {
+			t.Fatalf("step %d: Donchian %v-%v, want %v-%v", i, lower, upper, lo, hi)
+		}
+
+		var sx, sy, sxy, sxx, syy float64
+		for x, y := range w {
+			sx += float64(x)
+			sy += y
+			sxy += float64(x) * y
+			sxx += float64(x * x)
+			syy += y * y
+		}
+		n := float64(period)
+		slope, _ := lr.Value()
+		if want := (n*sxy - sx*sy) / (n*sxx - sx*sx); math.Abs(slope-want) > 1e-6 {
+			t.Fatalf("step %d: LinReg slope %v, want %v", i, slope, want)
+		}
+		r2, _ := lr.R2()
+		if want := (n*sxy - sx*sy) * (n*sxy - sx*sy) / ((n*sxx - sx*sx) * (n*syy - sy*sy)); math.Abs(r2-want) > 1e-6 {
+			t.Fatalf("step %d: LinReg R² %v, want %v", i, r2, want)
+		}
+
+		// MACD 线为两条 EMA 之差，信号线是 MACD 线自慢线预热完成起的 EMA
+		line, _ := macd.Value()
+		if want := lines[len(lines)-1]; math.Abs(line-want) > 1e-6 {
+			t.Fatalf("step %d: MACD %v, want %v", i, line, want)
+		}
+		if signal, ok := macd.Signal(); ok {
+			if want := ema(lines, 9); math.Abs(signal-want) > 1e-6 {
+				t.Fatalf("step %d: MACD signal %v, want %v", i, signal, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package metrics
+
+// MACD is the difference of a fast and a slow EMA, with an EMA of it as the signal line
+type MACD struct {
+	fast, slow, signal *EMA
+	slowPeriod         int
+	signalPeriod       int
+	count              int
+}
+
+// NewMACD creates a MACD, the classic periods are 12, 26 and 9
+func NewMACD(fast, slow, signal int) *MACD {
+	return &MACD{
+		fast:         NewEMAFromPeriod(fast),
+		slow:         NewEMAFromPeriod(slow),
+		signal:       NewEMAFromPeriod(signal),
+		slowPeriod:   max(slow, 1),
+		signalPeriod: max(signal, 1),
+	}
+}
+
+// Update adds a price
+func (m *MACD) Update(price float64) {
+	m.fast.Update(price)
+	m.slow.Update(price)
+	m.count++
+
+	// 慢线预热完成后才开始计算信号线
+	if m.count >= m.slowPeriod {
+		line, _ := m.Value()
+		m.signal.Update(line)
+	}
+}
+
+// Value returns the MACD line once the slow EMA has seen its period
+func (m *MACD) Value() (float64, bool) {
+	if m.count < m.slowPeriod {
+		return 0, false
+	}
+	fast, _ := m.fast.Value()
+	slow, _ := m.slow.Value()
+	return fast - slow, true
+}
+
+// Signal returns the signal line once it has seen its period of MACD values
+func (m *MACD) Signal() (float64, bool) {
+	if m.count < m.slowPeriod+m.signalPeriod-1 {
+		return 0, false
+	}
+	return m.signal.Value()
+}
+
+// Histogram returns MACD - signal
+func (m *MACD) Histogram() (float64, bool) {
+	signal, ok := m.Signal()
+	if !ok {
+		return 0, false
+	}
+	line, _ := m.Value()
+	return line - signal, true
+}
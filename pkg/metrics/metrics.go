@@ -60,6 +60,19 @@ func (w *RollingWindow[T]) Latest() (T, bool) {
 	return w.data[idx], true
 }
 
+// Oldest returns the least recently added value, the one the next Push evicts when full
+func (w *RollingWindow[T]) Oldest() (T, bool) {
+	var zero T
+	if w.count == 0 {
+		return zero, false
+	}
+
+	if w.count < w.size {
+		return w.data[0], true
+	}
+	return w.data[w.head], true
+}
+
 // Size returns the current number of elements in the window
 func (w *RollingWindow[T]) Size() int {
 	return w.count
@@ -0,0 +1,65 @@
+package metrics
+
+// LinReg fits a least-squares line to the last period values against their index.
+// The sums are updated on every value, shifting the x of the window, so an update is O(1).
+type LinReg struct {
+	window *RollingWindow[float64]
+	sumY   float64
+	sumXY  float64
+	sumYY  float64
+}
+
+// NewLinReg creates a linear regression over period values, at least 2
+func NewLinReg(period int) *LinReg {
+	return &LinReg{window: NewRollingWindow[float64](max(period, 2))}
+}
+
+// Update adds a value
+func (l *LinReg) Update(y float64) {
+	if l.window.IsFull() {
+		// 最早的值移出窗口，其余值的 x 各减 1
+		old, _ := l.window.Oldest()
+		l.sumY -= old
+		l.sumXY -= l.sumY
+		l.sumYY -= old * old
+	}
+
+	x := float64(l.window.Size())
+	if l.window.IsFull() {
+		x--
+	}
+	l.window.Push(y)
+	l.sumY += y
+	l.sumXY += x * y
+	l.sumYY += y * y
+}
+
+// fit returns the numerator and the x and y variance terms of the slope and R²
+func (l *LinReg) fit() (sxy, sxx, syy float64) {
+	n := float64(l.window.Size())
+	sumX := n * (n - 1) / 2
+	sumXX := (n - 1) * n * (2*n - 1) / 6
+	return n*l.sumXY - sumX*l.sumY, n*sumXX - sumX*sumX, n*l.sumYY - l.sumY*l.sumY
+}
+
+// Value returns the slope per value once the window is full
+func (l *LinReg) Value() (float64, bool) {
+	if !l.window.IsFull() {
+		return 0, false
+	}
+	sxy, sxx, _ := l.fit()
+	return sxy / sxx, true
+}
+
+// R2 returns the coefficient of determination of the fit; it is 0 for a flat window,
+// where no move is explained by a trend
+func (l *LinReg) R2() (float64, bool) {
+	if !l.window.IsFull() {
+		return 0, false
+	}
+	sxy, sxx, syy := l.fit()
+	if syy <= 0 {
+		return 0, true
+	}
+	return min(sxy*sxy/(sxx*syy), 1), true
+}
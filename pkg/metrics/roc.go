@@ -0,0 +1,29 @@
+package metrics
+
+// ROC is the rate of change in percent over period values: (p - p[n-period]) / p[n-period] * 100
+type ROC struct {
+	window *RollingWindow[float64]
+}
+
+// NewROC creates a rate of change over period values, default 10
+func NewROC(period int) *ROC {
+	if period <= 0 {
+		period = 10
+	}
+	return &ROC{window: NewRollingWindow[float64](period + 1)}
+}
+
+// Update adds a value
+func (r *ROC) Update(value float64) {
+	r.window.Push(value)
+}
+
+// Value returns the rate of change once period+1 values have been seen
+func (r *ROC) Value() (float64, bool) {
+	base, _ := r.window.Oldest()
+	if !r.window.IsFull() || base == 0 {
+		return 0, false
+	}
+	last, _ := r.window.Latest()
+	return (last - base) / base * 100, true
+}
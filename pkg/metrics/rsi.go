@@ -0,0 +1,56 @@
+package metrics
+
+// RSI is Wilder's Relative Strength Index. The first average gain and loss are the means
+// of the first period changes, later ones are smoothed as avg = (avg*(period-1) + x) / period.
+type RSI struct {
+	period  int
+	prev    float64
+	hasPrev bool
+	count   int // Changes seen, up to period
+	avgGain float64
+	avgLoss float64
+}
+
+// NewRSI creates an RSI over period changes, default 14
+func NewRSI(period int) *RSI {
+	if period <= 0 {
+		period = 14
+	}
+	return &RSI{period: period}
+}
+
+// Update adds a price
+func (r *RSI) Update(price float64) {
+	if !r.hasPrev {
+		r.prev, r.hasPrev = price, true
+		return
+	}
+
+	change := price - r.prev
+	r.prev = price
+	gain, loss := max(change, 0), max(-change, 0)
+
+	n := float64(r.period)
+	if r.count < r.period {
+		r.count++
+		r.avgGain += gain / n
+		r.avgLoss += loss / n
+		return
+	}
+	r.avgGain = (r.avgGain*(n-1) + gain) / n
+	r.avgLoss = (r.avgLoss*(n-1) + loss) / n
+}
+
+// Value returns the RSI in [0, 100] once period changes have been seen
+func (r *RSI) Value() (float64, bool) {
+	if r.count < r.period {
+		return 0, false
+	}
+	if r.avgLoss == 0 {
+		if r.avgGain == 0 {
+			return 50, true
+		}
+		return 100, true
+	}
+	return 100 - 100/(1+r.avgGain/r.avgLoss), true
+}
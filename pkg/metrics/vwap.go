@@ -0,0 +1,39 @@
+package metrics
+
+// VWAP is the volume weighted average price since the last Reset, e.g. a session open
+type VWAP struct {
+	pv     float64 // Σp·v
+	volume float64 // Σv
+}
+
+// NewVWAP creates an empty VWAP
+func NewVWAP() *VWAP {
+	return &VWAP{}
+}
+
+// Update adds a price traded with volume; bars pass their typical price and volume
+func (v *VWAP) Update(price, volume float64) {
+	if volume <= 0 {
+		return
+	}
+	v.pv += price * volume
+	v.volume += volume
+}
+
+// UpdateBar adds an OHLCV bar at its typical price (high + low + close) / 3
+func (v *VWAP) UpdateBar(b Bar) {
+	v.Update((b.High+b.Low+b.Close)/3, b.Volume)
+}
+
+// Value returns the VWAP once any volume has traded
+func (v *VWAP) Value() (float64, bool) {
+	if v.volume == 0 {
+		return 0, false
+	}
+	return v.pv / v.volume, true
+}
+
+// Reset starts a new VWAP period
+func (v *VWAP) Reset() {
+	v.pv, v.volume = 0, 0
+}
@@ -1,6 +1,14 @@
 package monitor
 
+import "github.com/wangpf09/golddog/pkg/alert"
+
 //type Monitor interface {
 //	Run(ctx context.Context) error
 //	Close() error
 //}
+
+// Sink receives dispatched alerts; *notify.Notifier is the production implementation
+type Sink interface {
+	Send(a *alert.AlertEvent) error
+	Close()
+}
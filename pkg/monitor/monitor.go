@@ -2,10 +2,13 @@ package monitor
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/clock"
 	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/fx"
 	"github.com/wangpf09/golddog/pkg/logger"
 	"github.com/wangpf09/golddog/pkg/metrics"
 	"github.com/wangpf09/golddog/pkg/notify"
@@ -13,46 +16,69 @@ import (
 )
 
 const (
-	windowSize   = 7200
-	pushInterval = 12 * time.Second
+	housekeepInterval = 10 * time.Second // 检查静默告警与定时报告的间隔
+	barCloseGrace     = 5 * time.Second  // K 线结束后仍接收迟到 tick 的时长
 )
 
 type Monitor struct {
-	source *source.SnapshotSource
+	source source.Source
+	alerts *config.AlertConfig
+	bars   *config.BarsConfig
+	market *config.MarketConfig
 
-	jumpDetector       *alert.JumpDetector
-	trendDetector      *alert.TrendDetector
-	volatilityDetector *alert.VolatilityDetector
+	sink    Sink
+	clock   clock.Clock
+	tracker *alert.Tracker
+	gate    *alert.Suppressor // 冷却与抑制，位于 dispatch 与 sink 之间
+	reports *reporter         // 定时行情报告，未启用时为 nil
+	fx      *fx.Rates         // 数据源的实时汇率，用于汇率过期告警
+	fxStale bool
 
-	notifier *notify.Notifier
+	tickTime    time.Time // 最新 tick 的时间戳
+	tickArrived time.Time // 最新 tick 到达的时间
 
-	priceWindow       *metrics.RollingWindow[source.NormalizedSnapshot]
-	priceChangeWindow *metrics.RollingWindow[source.Derived]
-
-	lastPush     time.Time
-	lastSnapshot source.NormalizedSnapshot
+	symbols map[string]*symbolState // 每个品种独立的窗口、检测器与采样节奏
 }
 
-func NewMonitor(conf *config.Config) (*Monitor, error) {
-	src, err := source.NewSnapshotSource(conf.QOSConfig)
-	if err != nil {
+// NewMonitor creates a monitor reading from src. Unless WithSink is given, alerts are
+// delivered by a notifier built from the notifier config section.
+func NewMonitor(conf *config.Config, src source.Source, opts ...Option) (*Monitor, error) {
+	alerts := conf.Alerts
+	if alerts == nil {
+		alerts = config.DefaultAlertConfig()
+	}
+	if err := alerts.Validate(); err != nil {
 		return nil, err
 	}
-
-	notifier, err := notify.NewNotifier(conf.Notifier)
-	if err != nil {
+	if _, err := alert.Build("", alerts); err != nil {
 		return nil, err
 	}
 
-	return &Monitor{
-		source:             src,
-		jumpDetector:       alert.NewJumpDetector(),
-		trendDetector:      alert.NewTrendDetector(),
-		volatilityDetector: alert.NewVolatilityDetector(),
-		notifier:           notifier,
-		priceWindow:        metrics.NewRollingWindow[source.NormalizedSnapshot](windowSize),
-		priceChangeWindow:  metrics.NewRollingWindow[source.Derived](windowSize),
-	}, nil
+	m := &Monitor{
+		source:  src,
+		alerts:  alerts,
+		bars:    conf.Bars,
+		market:  conf.Market,
+		clock:   clock.Real{},
+		tracker: alert.NewTracker(alerts.ResolveTimeout),
+		gate:    alert.NewSuppressor(alerts),
+		reports: newReporter(conf.Report, conf.Market, conf.Instruments),
+		symbols: make(map[string]*symbolState),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.sink == nil {
+		notifier, err := notify.NewNotifier(conf.Notifier)
+		if err != nil {
+			return nil, err
+		}
+		m.sink = notifier
+	}
+
+	return m, nil
 }
 
 func (m *Monitor) Run(ctx context.Context) error {
@@ -61,87 +87,345 @@ func (m *Monitor) Run(ctx context.Context) error {
 	}
 
 	logger.Infof("📈 gold monitor started")
-	_ = m.notifier.Send(&alert.AlertEvent{
+	_ = m.sink.Send(&alert.AlertEvent{
 		Type:      alert.AlertTypeHealth,
 		Severity:  alert.SeverityInfo,
 		Symbol:    "",
 		Message:   "📈 gold monitor started",
-		Timestamp: time.Now(),
+		Timestamp: m.clock.Now(),
 	})
+
+	// 数据源支持时上报断线与恢复
+	var status <-chan source.ConnEvent
+	if r, ok := m.source.(source.StatusReporter); ok {
+		status = r.Status()
+	}
+	if r, ok := m.source.(source.FXReporter); ok && r.FX().Live() {
+		m.fx = r.FX()
+	}
+
+	housekeeping := time.NewTicker(housekeepInterval)
+	defer housekeeping.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return m.Close()
 
+		case <-housekeeping.C:
+			now := m.clock.Now()
+			m.expireAlerts(now)
+			m.closeBars(now)
+			m.sendReports(now)
+			m.checkFX(now)
+
+		case e, ok := <-status:
+			if !ok {
+				status = nil
+				continue
+			}
+			m.handleConnEvent(e)
+
 		case snap, ok := <-m.source.Snapshots():
 			if !ok {
 				logger.Warn("snapshot channel closed")
-				return nil
+				return m.Close()
 			}
 			m.handleSnapshot(snap)
 		}
 	}
 }
 
-func (m *Monitor) handleSnapshot(snap source.NormalizedSnapshot) {
-	now := time.Now()
+// handleConnEvent turns a source connection change into a Health alert
+func (m *Monitor) handleConnEvent(e source.ConnEvent) {
+	if !e.Connected {
+		m.dispatch(&alert.AlertEvent{
+			Type:          alert.AlertTypeHealth,
+			Severity:      alert.SeverityCritical,
+			Message:       fmt.Sprintf("quote feed disconnected: %v", e.Err),
+			Timestamp:     e.Time,
+			Condition:     "connection",
+			SelfResolving: true,
+		})
+		return
+	}
+
+	m.dispatch(&alert.AlertEvent{
+		Type:      alert.AlertTypeHealth,
+		Severity:  alert.SeverityInfo,
+		Message:   fmt.Sprintf("quote feed recovered after %v (%d reconnect attempts)", e.Downtime.Round(time.Second), e.Attempts),
+		Timestamp: e.Time,
+		Condition: "connection",
+		State:     alert.StateResolved,
+	})
+}
+
+// checkFX fires a Health alert while the live FX rate is stale during a market session and
+// resolves it once the rate updates or the market closes
+func (m *Monitor) checkFX(now time.Time) {
+	if m.fx == nil {
+		return
+	}
+
+	// 休市期间汇率不更新是正常的，开盘前的静默也不计入
+	open, trading := m.market.SessionAt(now)
+	var age time.Duration
+	var stale bool
+	if trading {
+		age, stale = m.fx.Stale(now, open)
+	}
+	if stale == m.fxStale {
+		return
+	}
+	m.fxStale = stale
+
+	q := m.fx.Rate(now)
+	if stale {
+		m.dispatch(&alert.AlertEvent{
+			Type:          alert.AlertTypeHealth,
+			Severity:      alert.SeverityWarning,
+			Message:       fmt.Sprintf("FX rate not updated for %v, converting CNY prices with %.4f (%s)", age.Round(time.Second), q.Rate, q.Source),
+			Timestamp:     now,
+			Condition:     "fx",
+			SelfResolving: true,
+		})
+		return
+	}
+
+	msg := fmt.Sprintf("FX rate updated again: %.4f (%s)", q.Rate, q.Source)
+	if !trading {
+		msg = fmt.Sprintf("market closed, FX rate not checked until the next session: %.4f (%s)", q.Rate, q.Source)
+	}
+	m.dispatch(&alert.AlertEvent{
+		Type:      alert.AlertTypeHealth,
+		Severity:  alert.SeverityInfo,
+		Message:   msg,
+		Timestamp: now,
+		Condition: "fx",
+		State:     alert.StateResolved,
+	})
+}
 
-	if !m.lastPush.IsZero() && now.Sub(m.lastPush) < pushInterval {
+// Feed pushes a snapshot through the pipeline synchronously.
+// It is used by offline runs such as backtests, where the caller drives the clock.
+func (m *Monitor) Feed(snap source.NormalizedSnapshot) {
+	m.handleSnapshot(snap)
+}
+
+func (m *Monitor) handleSnapshot(snap source.NormalizedSnapshot) {
+	now := m.clock.Now()
+	st, err := m.state(snap.Symbol)
+	if err != nil {
+		logger.Errorf("failed to create detectors for %s: %v", snap.Symbol, err)
 		return
 	}
 
-	m.priceWindow.Push(snap)
-	m.lastPush = now
+	if snap.Timestamp.After(m.tickTime) {
+		m.tickTime, m.tickArrived = snap.Timestamp, now
+	}
+
+	m.expireAlerts(now)
+	m.closeBars(now)
+	m.sendReports(now)
+	if m.reports != nil {
+		m.reports.observe(snap)
+	}
+
+	// K 线使用逐笔成交量增量，成交量按日重置时记为 0
+	var volume float64
+	if !st.lastTick.Timestamp.IsZero() {
+		volume = max(source.NewDerived(st.lastTick, snap).VolumeDelta, 0)
+	}
+
+	// 健康检查等基于每个 tick，不受采样间隔限制
+	st.lastTick = snap
+	m.evaluateTick(st, snap, now)
+	m.updateBars(st, snap, volume, now)
+
+	// 按快照时间戳等间隔采样后再更新窗口与检测器
+	for _, sample := range st.sampler.add(snap) {
+		m.handleSample(st, sample, now)
+	}
+}
+
+// handleSample pushes one sample into the windows and runs the detectors
+func (m *Monitor) handleSample(st *symbolState, snap source.NormalizedSnapshot, now time.Time) {
+	st.priceWindow.Push(snap)
 
-	if m.priceWindow.Size() > 1 {
-		m.priceChangeWindow.Push(
-			source.NewDerived(m.lastSnapshot, snap),
-		)
+	if st.priceWindow.Size() > 1 {
+		d := source.NewDerived(st.lastSnapshot, snap)
+		st.priceChangeWindow.Push(d)
+		if m.reports != nil {
+			m.reports.observeChange(snap.Symbol, d)
+		}
 	}
 
-	if m.priceChangeWindow.Size() > 2 {
-		if !m.evaluate(snap) {
-			logger.Debugf("current price: %.2f 元/克", snap.LastPriceCNY)
+	if st.priceChangeWindow.Size() > 2 {
+		if !m.evaluate(st, snap, now) {
+			logger.Debugf("%s current price: %.2f 元/克", snap.Symbol, snap.LastPriceCNY)
 		}
 	}
 
-	m.lastSnapshot = snap
+	st.lastSnapshot = snap
 }
 
-func (m *Monitor) evaluate(snap source.NormalizedSnapshot) bool {
-	hasAlert := false
+// updateBars folds a raw tick into the bars of every timeframe
+func (m *Monitor) updateBars(st *symbolState, snap source.NormalizedSnapshot, volume float64, now time.Time) {
+	if snap.LastPrice <= 0 {
+		return
+	}
+	for _, b := range st.bars {
+		if bar, ok := b.Add(snap.Timestamp, snap.LastPrice, volume); ok {
+			m.handleBar(st, bar, now)
+		}
+	}
+}
+
+// closeBars closes the bars that ended without a later tick, e.g. at a session close.
+// Bars are keyed on tick time, so they close on the tick time reached at now plus a grace
+// period for late ticks, not on the wall clock.
+func (m *Monitor) closeBars(now time.Time) {
+	at := now
+	if !m.tickTime.IsZero() {
+		at = m.tickTime.Add(now.Sub(m.tickArrived))
+	}
+	at = at.Add(-barCloseGrace)
+
+	for _, st := range m.symbols {
+		for _, b := range st.bars {
+			if bar, ok := b.Due(at); ok {
+				m.handleBar(st, bar, now)
+			}
+		}
+	}
+}
+
+// handleBar publishes a closed bar to the bar windows, the reports and the bar detectors
+func (m *Monitor) handleBar(st *symbolState, bar metrics.Bar, now time.Time) {
+	st.barWindows[bar.Period].Push(bar)
+	if m.reports != nil {
+		m.reports.observeBar(st.symbol, bar)
+	}
+
+	ctx := st.context(st.lastTick, now)
+	for _, d := range st.detectors {
+		if bd, ok := d.(alert.BarDetector); ok {
+			if e := bd.EvaluateBar(ctx, bar); e != nil {
+				m.dispatch(e)
+			}
+		}
+	}
+}
 
-	if e := m.jumpDetector.Evaluate(m.priceChangeWindow); e != nil {
-		m.dispatch(e)
-		hasAlert = true
+// state returns the per-symbol state, creating it on the first snapshot of a symbol
+func (m *Monitor) state(symbol string) (*symbolState, error) {
+	st, ok := m.symbols[symbol]
+	if !ok {
+		var err error
+		if st, err = newSymbolState(symbol, m.alerts, m.bars, m.market); err != nil {
+			return nil, err
+		}
+		m.symbols[symbol] = st
 	}
+	return st, nil
+}
 
-	if e := m.trendDetector.Evaluate(snap.LastPrice); e != nil {
-		m.dispatch(e)
-		hasAlert = true
+func (m *Monitor) evaluateTick(st *symbolState, snap source.NormalizedSnapshot, now time.Time) {
+	ctx := st.context(snap, now)
+	for _, d := range st.detectors {
+		if td, ok := d.(alert.TickDetector); ok {
+			if e := td.EvaluateTick(ctx); e != nil {
+				m.dispatch(e)
+			}
+		}
 	}
+}
+
+func (m *Monitor) evaluate(st *symbolState, snap source.NormalizedSnapshot, now time.Time) bool {
+	ctx := st.context(snap, now)
 
-	if e := m.volatilityDetector.Evaluate(m.priceChangeWindow); e != nil {
-		m.dispatch(e)
-		hasAlert = true
+	hasAlert := false
+	for _, d := range st.detectors {
+		if e := d.Evaluate(ctx); e != nil {
+			m.dispatch(e)
+			hasAlert = true
+		}
 	}
 
 	return hasAlert
 }
 
+// expireAlerts resolves alerts that have gone quiet
+func (m *Monitor) expireAlerts(now time.Time) {
+	resolved, closed := m.tracker.Expire(now, m.lastPrice)
+	// 静默关闭的一次性告警不再抑制低级别告警
+	for _, fp := range closed {
+		m.gate.Close(fp)
+	}
+	for _, e := range resolved {
+		m.send(e)
+	}
+	m.gate.Prune(now)
+}
+
+// lastPrice returns the latest tick price of a symbol, 0 if none was seen
+func (m *Monitor) lastPrice(symbol string) float64 {
+	if st, ok := m.symbols[symbol]; ok {
+		return st.lastTick.LastPrice
+	}
+	return 0
+}
+
+// dispatch passes a detector event through the alert lifecycle and sends what needs notifying
 func (m *Monitor) dispatch(e *alert.AlertEvent) {
-	logger.Infof("🚨 ALERT: %s", e.String())
+	if e.Price == 0 {
+		e.Price = m.lastPrice(e.Symbol)
+	}
+
+	if e = m.tracker.Process(e); e == nil {
+		return
+	}
+	m.send(e)
+}
+
+func (m *Monitor) send(e *alert.AlertEvent) {
+	if ok, reason := m.gate.Allow(e); !ok {
+		logger.Infof("🔕 SUPPRESSED (%s): %s", reason, e.String())
+		return
+	}
+
+	if e.IsResolved() {
+		logger.Infof("✅ RESOLVED: %s", e.String())
+	} else {
+		logger.Infof("🚨 ALERT: %s", e.String())
+		if m.reports != nil {
+			m.reports.countAlert(e.Symbol)
+		}
+	}
 
-	if err := m.notifier.Send(e); err != nil {
+	if err := m.sink.Send(e); err != nil {
 		logger.Warnf("failed to send alert: %v", err)
 	}
 }
 
+// sendReports sends the scheduled reports that are due
+func (m *Monitor) sendReports(now time.Time) {
+	if m.reports == nil {
+		return
+	}
+	for _, e := range m.reports.due(now) {
+		logger.Infof("📰 REPORT: %s", e.String())
+		if err := m.sink.Send(e); err != nil {
+			logger.Warnf("failed to send report: %v", err)
+		}
+	}
+}
+
 func (m *Monitor) Close() error {
 	logger.Info("monitor shutting down")
 
-	if m.notifier != nil {
-		m.notifier.Close()
+	if m.sink != nil {
+		m.sink.Close()
 	}
 	return nil
 }
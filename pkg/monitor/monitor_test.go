@@ -0,0 +1,115 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/clock"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/fx"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+type discard struct{}
+
+func (discard) Send(*alert.AlertEvent) error { return nil }
+func (discard) Close()                       {}
+
+func TestCloseBarsOnTickTime(t *testing.T) {
+	t0 := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	clk := clock.NewVirtual(t0)
+	conf := &config.Config{Bars: &config.BarsConfig{Timeframes: []time.Duration{time.Minute}}}
+	m, err := NewMonitor(conf, nil, WithClock(clk), WithSink(discard{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tick 时间戳比本地时钟慢 30 秒
+	const lag = 30 * time.Second
+	feed := func(at time.Duration, price float64) {
+		clk.Set(t0.Add(at + lag))
+		m.Feed(source.NormalizedSnapshot{Symbol: "XAUUSD", LastPrice: price, Timestamp: t0.Add(at)})
+	}
+	feed(10*time.Second, 100)
+	feed(50*time.Second, 101)
+	feed(58*time.Second, 102)
+
+	// 本地时钟已过 K 线结束时间，但按 tick 时间尚未结束
+	clk.Set(t0.Add(time.Minute + lag))
+	m.closeBars(clk.Now())
+	st := m.symbols["XAUUSD"]
+	if n := st.barWindows[time.Minute].Size(); n != 0 {
+		t.Fatalf("%d bars closed before the tick time reached the bar end", n)
+	}
+
+	// 行情停止后按 tick 时间加宽限期收盘
+	clk.Set(t0.Add(time.Minute + lag + barCloseGrace))
+	m.closeBars(clk.Now())
+	bar, ok := st.barWindows[time.Minute].Latest()
+	if !ok || bar.Ticks != 3 || bar.Close != 102 {
+		t.Errorf("closed bar = %+v (%v), want 3 ticks closing at 102", bar, ok)
+	}
+}
+
+func TestClosedOneShotAlertStopsSuppressing(t *testing.T) {
+	t0 := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	sink := &recorder{}
+	m, err := NewMonitor(&config.Config{}, nil, WithClock(clock.NewVirtual(t0)), WithSink(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.dispatch(&alert.AlertEvent{Type: alert.AlertTypeJump, Symbol: "XAUUSD", Condition: "up", Severity: alert.SeverityCritical, Timestamp: t0, OneShot: true})
+
+	// resolve_timeout 内低级别告警被抑制，跳变告警静默关闭后恢复通知
+	level := func(at time.Duration, cond string) *alert.AlertEvent {
+		return &alert.AlertEvent{Type: alert.AlertTypeLevel, Symbol: "XAUUSD", Condition: cond, Severity: alert.SeverityWarning, Timestamp: t0.Add(at), OneShot: true}
+	}
+	m.dispatch(level(time.Minute, "above 2700.00"))
+	m.expireAlerts(t0.Add(6 * time.Minute))
+	m.dispatch(level(7*time.Minute, "above 2710.00"))
+
+	if len(sink.events) != 2 || sink.events[1].Condition != "above 2710.00" {
+		t.Fatalf("got %v, want the jump and the level alert after the jump closed", sink.events)
+	}
+}
+
+func TestCheckFXMarketClosed(t *testing.T) {
+	market := &config.MarketConfig{
+		Timezone: "UTC",
+		Sessions: []config.SessionConfig{{Name: "Day", Open: "09:00", Close: "17:00"}},
+		Days:     []string{"Mon", "Tue", "Wed", "Thu", "Fri"},
+	}
+	friday := time.Date(2030, 3, 1, 16, 55, 0, 0, time.UTC)
+	sink := &recorder{}
+	m, err := NewMonitor(&config.Config{Market: market}, nil, WithClock(clock.NewVirtual(friday)), WithSink(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.fx = fx.NewRates(&config.FXConfig{Symbol: "USDCNH", Rate: 7.1, MaxAge: 10 * time.Minute})
+	m.fx.Update(fx.Quote{Rate: 7.18, Time: friday, Source: "USDCNH"})
+
+	// 周末汇率不更新不告警；周一开盘后从开盘时间计算，超过 max_age 才告警
+	for _, at := range []time.Time{
+		friday.Add(4 * time.Minute),
+		time.Date(2030, 3, 2, 12, 0, 0, 0, time.UTC),
+		time.Date(2030, 3, 4, 9, 5, 0, 0, time.UTC),
+	} {
+		m.checkFX(at)
+		if len(sink.events) != 0 {
+			t.Fatalf("%v: unexpected alert %v", at, sink.events)
+		}
+	}
+
+	m.checkFX(time.Date(2030, 3, 4, 9, 11, 0, 0, time.UTC))
+	if len(sink.events) != 1 || sink.events[0].IsResolved() || sink.events[0].Condition != "fx" {
+		t.Fatalf("got %v, want a stale FX alert 11m after the open", sink.events)
+	}
+
+	// 收盘后恢复
+	m.checkFX(time.Date(2030, 3, 4, 17, 30, 0, 0, time.UTC))
+	if len(sink.events) != 2 || !sink.events[1].IsResolved() {
+		t.Fatalf("got %v, want the FX alert resolved at the close", sink.events)
+	}
+}
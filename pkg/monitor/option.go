@@ -0,0 +1,20 @@
+package monitor
+
+import "github.com/wangpf09/golddog/pkg/clock"
+
+// Option customizes a Monitor
+type Option func(m *Monitor)
+
+// WithClock replaces the wall clock, e.g. with a virtual clock for backtests
+func WithClock(c clock.Clock) Option {
+	return func(m *Monitor) {
+		m.clock = c
+	}
+}
+
+// WithSink sends alerts to s instead of creating a notifier from config
+func WithSink(s Sink) Option {
+	return func(m *Monitor) {
+		m.sink = s
+	}
+}
@@ -0,0 +1,259 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/metrics"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+// reporter produces the scheduled market summaries. Each schedule accumulates its own
+// per-symbol statistics since its previous report.
+type reporter struct {
+	schedules   []*reportSchedule
+	instruments config.Instruments
+}
+
+// reportSchedule is one recurring report, e.g. hourly or at the session opens and closes
+type reportSchedule struct {
+	next func(after time.Time) (time.Time, string) // Next due time and report name
+
+	due   time.Time
+	name  string
+	start time.Time
+	stats map[string]*periodStats
+}
+
+// periodStats 统计周期内单个品种的行情与告警
+type periodStats struct {
+	units  []string         // Quote unit first, then the display units
+	prices map[string]*ohlc // Keyed by unit
+
+	sumSq   float64 // Σr²，用于计算已实现波动率
+	samples int
+	alerts  int
+
+	widest metrics.Bar // 1h bar with the largest range, zero when none closed
+}
+
+type ohlc struct {
+	open, high, low, last float64
+}
+
+func newReporter(cfg *config.ReportConfig, market *config.MarketConfig, instruments config.Instruments) *reporter {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+
+	loc := market.GetLocation()
+	r := &reporter{instruments: instruments}
+
+	if cfg.Hourly {
+		r.add(func(after time.Time) (time.Time, string) {
+			return after.In(loc).Truncate(time.Hour).Add(time.Hour), "Hourly"
+		})
+	}
+
+	if cfg.Daily != "" {
+		at, _ := config.ParseTimeOfDay(cfg.Daily)
+		r.add(func(after time.Time) (time.Time, string) {
+			return nextTimeOfDay(after, at, loc), "Daily"
+		})
+	}
+
+	if cfg.Sessions {
+		type event struct {
+			at   time.Duration
+			name string
+		}
+		var events []event
+		for _, s := range market.GetSessions() {
+			open, _ := config.ParseTimeOfDay(s.Open)
+			closing, _ := config.ParseTimeOfDay(s.Close)
+			events = append(events, event{open, s.Name + " open"}, event{closing, s.Name + " close"})
+		}
+
+		r.add(func(after time.Time) (time.Time, string) {
+			var due time.Time
+			var name string
+			for _, e := range events {
+				if t := nextTimeOfDay(after, e.at, loc); due.IsZero() || t.Before(due) {
+					due, name = t, e.name
+				}
+			}
+			return due, name
+		})
+	}
+
+	return r
+}
+
+func (r *reporter) add(next func(after time.Time) (time.Time, string)) {
+	r.schedules = append(r.schedules, &reportSchedule{next: next, stats: make(map[string]*periodStats)})
+}
+
+// nextTimeOfDay returns the first time after `after` at offset from midnight in loc
+func nextTimeOfDay(after time.Time, offset time.Duration, loc *time.Location) time.Time {
+	day := after.In(loc)
+	for {
+		if due := config.AtTimeOfDay(day, offset); due.After(after) {
+			return due
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
+
+// observe updates the open/high/low/last of every schedule with a raw snapshot,
+// in the quote unit and the display units of the symbol
+func (r *reporter) observe(snap source.NormalizedSnapshot) {
+	if snap.LastPrice <= 0 {
+		return
+	}
+
+	for _, s := range r.schedules {
+		st := s.symbol(snap.Symbol)
+		if st.units == nil {
+			st.units = []string{snap.GetUnit()}
+			for _, u := range r.instruments.Get(snap.Symbol).DisplayUnits {
+				if !slices.Contains(st.units, u) {
+					st.units = append(st.units, u)
+				}
+			}
+		}
+
+		for _, u := range st.units {
+			price, ok := snap.Price(u)
+			if !ok {
+				continue
+			}
+			p, ok := st.prices[u]
+			if !ok {
+				p = &ohlc{open: price, high: price, low: price}
+				st.prices[u] = p
+			}
+			p.high, p.low, p.last = max(p.high, price), min(p.low, price), price
+		}
+	}
+}
+
+// observeChange adds a sampled price change, the values of priceChangeWindow
+func (r *reporter) observeChange(symbol string, d source.Derived) {
+	for _, s := range r.schedules {
+		st := s.symbol(symbol)
+		st.sumSq += d.PriceChangeRate * d.PriceChangeRate
+		st.samples++
+	}
+}
+
+// observeBar keeps the hourly bar with the largest range of the period
+func (r *reporter) observeBar(symbol string, bar metrics.Bar) {
+	if bar.Period != time.Hour {
+		return
+	}
+	for _, s := range r.schedules {
+		if st := s.symbol(symbol); bar.Range() > st.widest.Range() || st.widest.Ticks == 0 {
+			st.widest = bar
+		}
+	}
+}
+
+// countAlert counts a fired alert of symbol, empty for feed-wide alerts
+func (r *reporter) countAlert(symbol string) {
+	for _, s := range r.schedules {
+		s.symbol(symbol).alerts++
+	}
+}
+
+func (s *reportSchedule) symbol(symbol string) *periodStats {
+	st, ok := s.stats[symbol]
+	if !ok {
+		st = &periodStats{prices: make(map[string]*ohlc)}
+		s.stats[symbol] = st
+	}
+	return st
+}
+
+// due returns the reports that are due at now and starts their next period
+func (r *reporter) due(now time.Time) []*alert.AlertEvent {
+	var reports []*alert.AlertEvent
+	for _, s := range r.schedules {
+		if s.due.IsZero() {
+			s.start = now
+			s.due, s.name = s.next(now)
+			continue
+		}
+		if now.Before(s.due) {
+			continue
+		}
+
+		if e := s.report(now, r.instruments); e != nil {
+			reports = append(reports, e)
+		}
+		s.start = now
+		s.stats = make(map[string]*periodStats)
+		s.due, s.name = s.next(now)
+	}
+	return reports
+}
+
+// report renders the period of the schedule, nil when no symbol had data
+func (s *reportSchedule) report(now time.Time, instruments config.Instruments) *alert.AlertEvent {
+	symbols := make([]string, 0, len(s.stats))
+	for symbol, st := range s.stats {
+		if symbol != "" && len(st.units) > 0 && st.prices[st.units[0]] != nil {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+	sort.Strings(symbols)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s – %s", s.start.Format("01-02 15:04"), now.Format("01-02 15:04"))
+	for _, symbol := range symbols {
+		st, inst := s.stats[symbol], instruments.Get(symbol)
+		quote := st.prices[st.units[0]]
+		fmt.Fprintf(&b, "\n\n**%s** %+.2f%%\n", symbol, (quote.last-quote.open)/quote.open*100)
+
+		for i, u := range st.units {
+			p, ok := st.prices[u]
+			if !ok {
+				continue
+			}
+			// 报价单位按最小变动价位的精度显示
+			decimals := 2
+			if i == 0 {
+				decimals = inst.Decimals()
+			}
+			fmt.Fprintf(&b, "O %.*f  H %.*f  L %.*f  C %.*f %s", decimals, p.open, decimals, p.high, decimals, p.low, decimals, p.last, u)
+			if m := inst.GetMultiplier(); i == 0 && m != 1 {
+				currency, _, _ := strings.Cut(u, "/")
+				fmt.Fprintf(&b, " · lot %.2f %s", p.last*m, currency)
+			}
+			b.WriteString("\n")
+		}
+		if w := st.widest; w.Ticks > 0 {
+			fmt.Fprintf(&b, "Widest 1h bar %s range %.*f\n", w.Start.In(s.start.Location()).Format("15:04"), inst.Decimals(), w.Range())
+		}
+		fmt.Fprintf(&b, "Realized vol %.3f%% (%d samples) · %d alerts", math.Sqrt(st.sumSq)*100, st.samples, st.alerts)
+	}
+	if st, ok := s.stats[""]; ok && st.alerts > 0 {
+		fmt.Fprintf(&b, "\n\nFeed alerts: %d", st.alerts)
+	}
+
+	return &alert.AlertEvent{
+		Type:      alert.AlertTypeReport,
+		Severity:  alert.SeverityInfo,
+		Condition: s.name,
+		Message:   b.String(),
+		Timestamp: now,
+	}
+}
@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/metrics"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+func TestReporterSchedules(t *testing.T) {
+	market := &config.MarketConfig{
+		Timezone: "Asia/Shanghai",
+		Sessions: []config.SessionConfig{{Name: "London", Open: "15:00", Close: "23:30"}},
+	}
+	r := newReporter(&config.ReportConfig{Enabled: true, Hourly: true, Daily: "16:30", Sessions: true}, market, nil)
+
+	loc := market.GetLocation()
+	start := time.Date(2026, 1, 5, 14, 20, 0, 0, loc)
+
+	var names []string
+	for now := start; now.Before(start.Add(10 * time.Hour)); now = now.Add(time.Minute) {
+		for _, e := range r.due(now) {
+			names = append(names, e.Condition+"@"+e.Timestamp.In(loc).Format("15:04"))
+		}
+		r.observe(source.NormalizedSnapshot{Symbol: "XAUUSD", LastPrice: 2650, Timestamp: now})
+	}
+
+	want := "Hourly@15:00 London open@15:00 Hourly@16:00 Daily@16:30 Hourly@17:00 Hourly@18:00 Hourly@19:00 " +
+		"Hourly@20:00 Hourly@21:00 Hourly@22:00 Hourly@23:00 London close@23:30 Hourly@00:00"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("reports:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestNextTimeOfDayDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// 2026-03-08 与 2026-11-01 为夏令时切换日
+	tests := []struct {
+		after time.Time
+		want  time.Time
+	}{
+		{time.Date(2026, 3, 7, 20, 0, 0, 0, loc), time.Date(2026, 3, 8, 17, 0, 0, 0, loc)},
+		{time.Date(2026, 3, 8, 1, 0, 0, 0, loc), time.Date(2026, 3, 8, 17, 0, 0, 0, loc)},
+		{time.Date(2026, 10, 31, 20, 0, 0, 0, loc), time.Date(2026, 11, 1, 17, 0, 0, 0, loc)},
+		{time.Date(2026, 11, 1, 0, 30, 0, 0, loc), time.Date(2026, 11, 1, 17, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := nextTimeOfDay(tt.after, 17*time.Hour, loc); !got.Equal(tt.want) {
+			t.Errorf("nextTimeOfDay(%v, 17:00) = %v, want %v", tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestReporterStats(t *testing.T) {
+	instruments := config.Instruments{
+		"XAUUSD": {DisplayUnits: []string{"CNY/g", "USD/oz"}},
+		"AU9999": {QuoteCurrency: "CNY", QuoteUnit: "g", DisplayUnits: []string{"CNY/kg"}, Multiplier: 1000, TickSize: 0.01},
+	}
+	r := newReporter(&config.ReportConfig{Enabled: true, Hourly: true}, &config.MarketConfig{Timezone: "UTC"}, instruments)
+	fx := config.UnitGrams["oz"] / 4 // 1 USD/oz = 0.25 CNY/g
+
+	t0 := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	r.due(t0)
+	for i, p := range []float64{2650, 2670, 2640, 2660} {
+		r.observe(source.NormalizedSnapshot{Symbol: "XAUUSD", LastPrice: p, FXRate: fx, Timestamp: t0.Add(time.Duration(i) * time.Minute)})
+	}
+	r.observe(source.NormalizedSnapshot{Symbol: "AU9999", LastPrice: 612.345, Unit: "CNY/g", FXRate: fx, Timestamp: t0})
+	r.observeChange("XAUUSD", source.Derived{PriceChangeRate: 0.003})
+	r.observeChange("XAUUSD", source.Derived{PriceChangeRate: -0.004})
+	r.countAlert("XAUUSD")
+	r.observeBar("XAUUSD", metrics.Bar{Period: time.Hour, Start: t0, High: 2670, Low: 2640, Ticks: 4})
+	r.observeBar("XAUUSD", metrics.Bar{Period: time.Minute, Start: t0, High: 2700, Low: 2600, Ticks: 1})
+
+	reports := r.due(t0.Add(time.Hour))
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+
+	msg := reports[0].Message
+	for _, s := range []string{
+		"**XAUUSD** +0.38%",
+		"O 2650.00  H 2670.00  L 2640.00  C 2660.00 USD/oz",
+		"O 662.50  H 667.50  L 660.00  C 665.00 CNY/g",
+		"**AU9999** +0.00%",
+		"O 612.35  H 612.35  L 612.35  C 612.35 CNY/g · lot 612345.00 CNY",
+		"C 612345.00 CNY/kg",
+		"Widest 1h bar 10:00 range 30.00",
+		"Realized vol 0.500% (2 samples) · 1 alerts",
+	} {
+		if !strings.Contains(msg, s) {
+			t.Errorf("report %q does not contain %q", msg, s)
+		}
+	}
+
+	// 新周期没有数据时不发送
+	if reports := r.due(t0.Add(2 * time.Hour)); len(reports) != 0 {
+		t.Errorf("got %d reports for an empty period", len(reports))
+	}
+}
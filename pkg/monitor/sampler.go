@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/logger"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+// sampler resamples the raw snapshots of one symbol into evenly spaced samples keyed on
+// the snapshot timestamps. A sample is stamped with the end of its interval and emitted
+// once a snapshot of a later interval arrives, so arrival jitter does not matter.
+type sampler struct {
+	interval time.Duration
+	mode     string
+	maxGap   time.Duration
+
+	bucket time.Time                 // Start of the open interval, zero before the first snapshot
+	last   source.NormalizedSnapshot // Latest snapshot of the open interval
+	count  int
+	sum    float64 // Σp, mean mode
+	pv     float64 // Σp·Δv, vwap mode
+	volume float64 // ΣΔv
+
+	prevVolume float64 // Cumulative volume of the previous snapshot
+	hasPrev    bool
+}
+
+func newSampler(cfg config.SamplingConfig) *sampler {
+	mode := cfg.Mode
+	if mode == "" {
+		mode = config.SamplingLast
+	}
+	return &sampler{interval: cfg.Interval, mode: mode, maxGap: cfg.MaxGap}
+}
+
+// add folds snap into its interval and returns the samples completed by it: the previous
+// interval and, for gaps up to max_gap, flat samples for the intervals without data
+func (s *sampler) add(snap source.NormalizedSnapshot) []source.NormalizedSnapshot {
+	bucket := snap.Timestamp.Truncate(s.interval)
+
+	var samples []source.NormalizedSnapshot
+	switch {
+	case s.bucket.IsZero():
+		s.bucket = bucket
+	case bucket.Before(s.bucket):
+		// 已输出区间的迟到快照不再计入
+		logger.Debugf("%s late snapshot at %s dropped from sampling", snap.Symbol, snap.Timestamp.Format(time.RFC3339))
+		return nil
+	case bucket.After(s.bucket):
+		samples = s.close(bucket)
+		s.bucket = bucket
+	}
+
+	s.accumulate(snap)
+	return samples
+}
+
+func (s *sampler) accumulate(snap source.NormalizedSnapshot) {
+	var dv float64
+	if s.hasPrev && snap.Volume > s.prevVolume {
+		dv = snap.Volume - s.prevVolume // 成交量按日重置时记为 0
+	}
+	s.prevVolume, s.hasPrev = snap.Volume, true
+
+	s.last = snap
+	s.count++
+	s.sum += snap.LastPrice
+	s.pv += snap.LastPrice * dv
+	s.volume += dv
+}
+
+// close emits the open interval and the empty intervals before next
+func (s *sampler) close(next time.Time) []source.NormalizedSnapshot {
+	sample := s.last
+	if price := s.price(); price != sample.LastPrice && sample.LastPrice != 0 {
+		sample.LastPriceCNY *= price / sample.LastPrice
+		sample.LastPrice = price
+	}
+	sample.Timestamp = s.bucket.Add(s.interval)
+
+	samples := []source.NormalizedSnapshot{sample}
+	if next.Sub(sample.Timestamp) <= s.maxGap {
+		flat := sample
+		for t := sample.Timestamp.Add(s.interval); !t.After(next); t = t.Add(s.interval) {
+			flat.Timestamp = t
+			samples = append(samples, flat)
+		}
+	}
+
+	s.count, s.sum, s.pv, s.volume = 0, 0, 0, 0
+	return samples
+}
+
+// price returns the sampled price of the open interval
+func (s *sampler) price() float64 {
+	switch s.mode {
+	case config.SamplingMean:
+		return s.sum / float64(s.count)
+	case config.SamplingVWAP:
+		if s.volume > 0 {
+			return s.pv / s.volume
+		}
+		// 区间内无成交时退化为均价
+		return s.sum / float64(s.count)
+	default:
+		return s.last.LastPrice
+	}
+}
@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+func TestSampler(t *testing.T) {
+	t0 := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	ticks := []struct {
+		at     time.Duration
+		price  float64
+		volume float64
+	}{
+		{1 * time.Second, 100, 10},
+		{4 * time.Second, 102, 20},
+		{9 * time.Second, 104, 50},
+		{12 * time.Second, 106, 60},
+		{8 * time.Second, 90, 60}, // 迟到，丢弃
+		{45 * time.Second, 108, 70},
+		{200 * time.Second, 110, 80},
+	}
+
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{config.SamplingLast, "10s=104.00 20s=106.00 30s=106.00 40s=106.00 50s=108.00"},
+		{config.SamplingMean, "10s=102.00 20s=106.00 30s=106.00 40s=106.00 50s=108.00"},
+		{config.SamplingVWAP, "10s=103.50 20s=106.00 30s=106.00 40s=106.00 50s=108.00"},
+	}
+
+	for _, tt := range tests {
+		s := newSampler(config.SamplingConfig{Interval: 10 * time.Second, Mode: tt.mode, MaxGap: 30 * time.Second})
+
+		var got []string
+		for _, tick := range ticks {
+			snap := source.NormalizedSnapshot{Symbol: "XAUUSD", LastPrice: tick.price, Volume: tick.volume, Timestamp: t0.Add(tick.at)}
+			for _, sample := range s.add(snap) {
+				got = append(got, fmt.Sprintf("%v=%.2f", sample.Timestamp.Sub(t0), sample.LastPrice))
+			}
+		}
+
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.mode, strings.Join(got, " "), tt.want)
+		}
+	}
+}
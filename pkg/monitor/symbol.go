@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/metrics"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+const windowSize = 7200
+
+// symbolState holds the windows, detectors and sampler of one symbol
+// so that derived values are never computed across different instruments
+type symbolState struct {
+	symbol    string
+	detectors []alert.Detector
+
+	priceWindow       *metrics.RollingWindow[source.NormalizedSnapshot]
+	priceChangeWindow *metrics.RollingWindow[source.Derived]
+
+	sampler      *sampler
+	interval     time.Duration
+	bars         []*metrics.BarBuilder
+	barWindows   map[time.Duration]*metrics.RollingWindow[metrics.Bar]
+	lastSnapshot source.NormalizedSnapshot // Latest sampled snapshot
+	lastTick     source.NormalizedSnapshot // Latest raw snapshot
+}
+
+func newSymbolState(symbol string, alerts *config.AlertConfig, bars *config.BarsConfig, market *config.MarketConfig) (*symbolState, error) {
+	// 只创建启用的检测器
+	detectors, err := alert.Build(symbol, alerts)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []metrics.Session
+	for _, s := range market.GetSessions() {
+		open, _ := config.ParseTimeOfDay(s.Open)
+		closing, _ := config.ParseTimeOfDay(s.Close)
+		sessions = append(sessions, metrics.Session{Name: s.Name, Open: open, Close: closing})
+	}
+
+	st := &symbolState{
+		symbol:            symbol,
+		detectors:         detectors,
+		priceWindow:       metrics.NewRollingWindow[source.NormalizedSnapshot](windowSize),
+		priceChangeWindow: metrics.NewRollingWindow[source.Derived](windowSize),
+		sampler:           newSampler(alerts.Sampling),
+		interval:          alerts.Sampling.Interval,
+		barWindows:        make(map[time.Duration]*metrics.RollingWindow[metrics.Bar]),
+	}
+	for _, tf := range bars.GetTimeframes() {
+		st.bars = append(st.bars, metrics.NewBarBuilder(tf, market.GetLocation(), sessions))
+		st.barWindows[tf] = metrics.NewRollingWindow[metrics.Bar](bars.GetHistory())
+	}
+	return st, nil
+}
+
+// context builds the detector view of the latest snapshot
+func (st *symbolState) context(snap source.NormalizedSnapshot, now time.Time) *alert.Context {
+	return &alert.Context{
+		Symbol:   st.symbol,
+		Snapshot: snap,
+		Prices:   st.priceWindow,
+		Changes:  st.priceChangeWindow,
+		Interval: st.interval,
+		Bars:     st.barWindows,
+		Now:      now,
+	}
+}
@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/clock"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/source"
+)
+
+// recorder collects the alerts sent by the monitor
+type recorder struct {
+	events []*alert.AlertEvent
+}
+
+func (r *recorder) Send(e *alert.AlertEvent) error {
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) Close() {}
+
+func TestMonitorSymbols(t *testing.T) {
+	alerts := config.DefaultAlertConfig()
+	alerts.Trend.Enabled = false
+	alerts.Volatility.Enabled = false
+	alerts.Jump.ZThreshold = 3
+	alerts.Jump.StdFloor = 0
+	alerts.Health.Enabled = true
+	alerts.Health.MaxUnchangedPrice = 3
+
+	t0 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	clk := clock.NewVirtual(t0)
+	sink := &recorder{}
+	m, err := NewMonitor(&config.Config{Alerts: alerts}, nil, WithClock(clk), WithSink(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 两个品种同一时刻交替推送：XAUUSD 价格冻结，XAGUSD 小幅波动后跳涨；
+	// 多推一个快照让采样器输出最后一个区间
+	const ticks = 20
+	for i := range ticks + 1 {
+		now := t0.Add(time.Duration(i) * 12 * time.Second)
+		clk.Set(now)
+
+		silver := 31.0
+		if i%2 == 1 {
+			silver += 0.1
+		}
+		if i >= ticks-1 {
+			silver += 5
+		}
+		m.Feed(source.NormalizedSnapshot{Symbol: "XAUUSD", LastPrice: 2650, Volume: float64(i), Turnover: float64(i), Timestamp: now})
+		m.Feed(source.NormalizedSnapshot{Symbol: "XAGUSD", LastPrice: silver, Volume: float64(i), Turnover: float64(i), Timestamp: now})
+	}
+
+	if len(m.symbols) != 2 {
+		t.Fatalf("got %d symbol states, want 2", len(m.symbols))
+	}
+	gold, silver := m.symbols["XAUUSD"], m.symbols["XAGUSD"]
+	if len(gold.detectors) != 2 || len(silver.detectors) != 2 {
+		t.Fatalf("got %d and %d detectors, want jump and health for each symbol", len(gold.detectors), len(silver.detectors))
+	}
+	for i := range gold.detectors {
+		if gold.detectors[i] == silver.detectors[i] {
+			t.Errorf("symbols share the %s detector", gold.detectors[i].Name())
+		}
+	}
+	if gold.sampler == silver.sampler {
+		t.Error("symbols share the sampler")
+	}
+	for i := range gold.bars {
+		if gold.bars[i] == silver.bars[i] {
+			t.Errorf("symbols share the bar builder #%d", i)
+		}
+	}
+	for _, st := range []*symbolState{gold, silver} {
+		// 每个品种各自采样，窗口只包含本品种的快照
+		if n := st.priceWindow.Size(); n != ticks {
+			t.Errorf("%s: window has %d snapshots, want %d", st.symbol, n, ticks)
+		}
+		for _, s := range st.priceWindow.Values() {
+			if s.Symbol != st.symbol {
+				t.Errorf("%s: window holds a %s snapshot", st.symbol, s.Symbol)
+			}
+		}
+		// 4 根已收盘的 1 分钟 K 线，每根只含本品种的 5 个 tick
+		bars := st.barWindows[time.Minute].Values()
+		if len(bars) != 4 {
+			t.Errorf("%s: got %d minute bars, want 4", st.symbol, len(bars))
+		}
+		for _, b := range bars {
+			if b.Ticks != 5 || b.High-b.Low > 10 {
+				t.Errorf("%s: bar %v has %d ticks in %v..%v", st.symbol, b.Start, b.Ticks, b.Low, b.High)
+			}
+		}
+		for _, d := range st.priceChangeWindow.Values() {
+			if d.PriceChange > 10 || d.PriceChange < -10 {
+				t.Errorf("%s: price change %v computed across symbols", st.symbol, d.PriceChange)
+			}
+		}
+	}
+
+	want := map[alert.AlertType]string{alert.AlertTypeHealth: "XAUUSD", alert.AlertTypeJump: "XAGUSD"}
+	if len(sink.events) != len(want) {
+		t.Fatalf("got %d alerts, want %d: %v", len(sink.events), len(want), sink.events)
+	}
+	for _, e := range sink.events {
+		if e.Symbol != want[e.Type] {
+			t.Errorf("%s alert for %q, want %q", e.Type, e.Symbol, want[e.Type])
+		}
+	}
+}
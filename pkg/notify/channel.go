@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+// Channel renders alerts for one chat tool and delivers them.
+// Format runs once per alert; Deliver is retried with the same payload.
+type Channel interface {
+	Format(a *alert.AlertEvent) ([]byte, error)
+	FormatDigest(events []*alert.AlertEvent) ([]byte, error)
+	Deliver(ctx context.Context, payload []byte) error
+}
+
+// NewChannel creates the channel implementation selected by cfg.Type
+func NewChannel(cfg config.ChannelConfig) (Channel, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	switch cfg.GetType() {
+	case config.ChannelFeishu:
+		return NewFeishuChannel(cfg.WebhookURL, cfg.Secret, cfg.Keywords, client), nil
+	case config.ChannelSlack:
+		return &SlackChannel{url: cfg.WebhookURL, client: client}, nil
+	case config.ChannelDingTalk:
+		return &DingTalkChannel{url: cfg.WebhookURL, client: client}, nil
+	case config.ChannelWeCom:
+		return &WeComChannel{url: cfg.WebhookURL, client: client}, nil
+	case config.ChannelTelegram:
+		return NewTelegramChannel(cfg.WebhookURL, cfg.BotToken, cfg.ChatID, client), nil
+	default:
+		return nil, fmt.Errorf("unknown channel type %s", cfg.Type)
+	}
+}
+
+// postJSON posts body and returns the response body of a 2xx response
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte) ([]byte, error) {
+	// 请求绑定 Context，以便关闭时能取消正在进行的 HTTP 请求
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// markdownLines renders the alert fields as "**Label**: value" lines shared by the markdown channels
+func markdownLines(a *alert.AlertEvent) []string {
+	lines := []string{
+		fmt.Sprintf("**Severity**: %s", a.Severity),
+		fmt.Sprintf("**Time**: %s", a.Timestamp.Format("2006-01-02 15:04:05")),
+	}
+	if a.IsResolved() {
+		lines = append(lines,
+			fmt.Sprintf("**Duration**: %v", a.Duration().Round(time.Second)),
+			fmt.Sprintf("**Price**: %.2f → %.2f (%+.2f)", a.StartPrice, a.Price, a.Price-a.StartPrice),
+		)
+	}
+	lines = append(lines,
+		fmt.Sprintf("**Message**: %s", a.Message),
+		fmt.Sprintf("Alert ID: %s", a.ID()),
+	)
+	return lines
+}
+
+// digestTitle returns the title of a digest, e.g. "📋 Alert digest: 12 alerts (10:00–10:15)"
+func digestTitle(events []*alert.AlertEvent) string {
+	first, last := events[0].Timestamp, events[len(events)-1].Timestamp
+	return fmt.Sprintf("📋 Alert digest: %d alerts (%s–%s)", len(events), first.Format("15:04"), last.Format("15:04"))
+}
+
+// digestLine renders one alert of a digest without markup
+func digestLine(a *alert.AlertEvent) string {
+	state := ""
+	if a.IsResolved() {
+		state = " resolved"
+	}
+	return fmt.Sprintf("%s %s [%s] %s %s%s: %s",
+		a.Timestamp.Format("15:04:05"), a.Emoji(), a.Severity, a.Type, a.Symbol, state, a.Message)
+}
@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+func TestChannels(t *testing.T) {
+	a := &alert.AlertEvent{
+		Type:        alert.AlertTypeJump,
+		Severity:    alert.SeverityCritical,
+		Symbol:      "XAUUSD",
+		Message:     "price jumped <3.2>",
+		Timestamp:   time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC),
+		Fingerprint: "abc123",
+	}
+
+	tests := []struct {
+		typ      string
+		response string
+		path     string   // Expected request path
+		contains []string // Expected payload fragments
+		fail     bool
+	}{
+		{config.ChannelFeishu, `{"code":0,"msg":"success"}`, "/", []string{`"msg_type":"interactive"`, "Jump Alert - XAUUSD"}, false},
+		{config.ChannelFeishu, `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`, "/", nil, true},
+		{config.ChannelFeishu, `{"StatusCode":0,"StatusMessage":"success"}`, "/", nil, false},
+		{config.ChannelSlack, `ok`, "/", []string{`"type":"header"`, `price jumped &lt;3.2&gt;`}, false},
+		{config.ChannelDingTalk, `{"errcode":0,"errmsg":"ok"}`, "/", []string{`"msgtype":"markdown"`, "Alert ID: abc123"}, false},
+		{config.ChannelDingTalk, `{"errcode":310000,"errmsg":"keywords not in content"}`, "/", nil, true},
+		{config.ChannelWeCom, `{"errcode":0,"errmsg":"ok"}`, "/", []string{`<font color=\"warning\">`}, false},
+		{config.ChannelWeCom, `{"errcode":93000,"errmsg":"invalid webhook url"}`, "/", nil, true},
+		{config.ChannelTelegram, `{"ok":true}`, "/botT0KEN/sendMessage", []string{`"chat_id":"42"`, `"parse_mode":"HTML"`, "&lt;3.2&gt;"}, false},
+		{config.ChannelTelegram, `{"ok":false,"description":"chat not found"}`, "/botT0KEN/sendMessage", nil, true},
+	}
+
+	for _, tt := range tests {
+		var gotPath, gotBody string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			gotPath, gotBody = r.URL.Path, unescapeJSON(body)
+			_, _ = io.WriteString(w, tt.response)
+		}))
+
+		ch, err := NewChannel(config.ChannelConfig{
+			Type:       tt.typ,
+			WebhookURL: srv.URL,
+			BotToken:   "T0KEN",
+			ChatID:     "42",
+			Timeout:    time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		payload, err := ch.Format(a)
+		if err != nil {
+			t.Fatalf("%s: format: %v", tt.typ, err)
+		}
+		if !json.Valid(payload) {
+			t.Errorf("%s: invalid JSON payload %s", tt.typ, payload)
+		}
+
+		err = ch.Deliver(context.Background(), payload)
+		srv.Close()
+
+		if (err != nil) != tt.fail {
+			t.Errorf("%s: deliver err=%v, want fail=%v", tt.typ, err, tt.fail)
+		}
+		if gotPath != tt.path {
+			t.Errorf("%s: request path %s, want %s", tt.typ, gotPath, tt.path)
+		}
+		for _, s := range tt.contains {
+			if !strings.Contains(gotBody, s) {
+				t.Errorf("%s: payload %s does not contain %s", tt.typ, gotBody, s)
+			}
+		}
+	}
+}
+
+func TestFeishuSignAndKeywords(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = io.WriteString(w, `{"code":0}`)
+	}))
+	defer srv.Close()
+
+	c := NewFeishuChannel(srv.URL, "demo-secret", []string{"金价"}, srv.Client())
+	c.now = func() time.Time { return time.Unix(1599360473, 0) }
+
+	payload, err := c.Format(&alert.AlertEvent{Type: alert.AlertTypeTrend, Symbol: "XAUUSD", Message: "uptrend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(payload), "金价") {
+		t.Errorf("payload %s does not contain the keyword", payload)
+	}
+
+	if err := c.Deliver(context.Background(), payload); err != nil {
+		t.Fatal(err)
+	}
+
+	// base64(HmacSHA256(key = "1599360473\ndemo-secret", data = ""))
+	if got["timestamp"] != "1599360473" || got["sign"] != "3/MaVZ8JLIy4TUG+7KSFJqvUkTKd+HWY8g+56DZWq8s=" {
+		t.Errorf("timestamp=%v sign=%v", got["timestamp"], got["sign"])
+	}
+	if got["msg_type"] != "interactive" {
+		t.Errorf("card lost while signing: %v", got)
+	}
+}
+
+func TestFeishuKeywordInText(t *testing.T) {
+	tests := []struct {
+		keyword string
+		message string
+		added   bool
+	}{
+		{"uptrend", "uptrend", false},
+		{"XAUUSD", "", false},     // 标题中的品种
+		{"card", "uptrend", true}, // 只出现在 JSON 键名与 tag 中
+		{"interactive", "uptrend", true},
+		{"div", "uptrend", true},
+		{"<告警>", "<告警> uptrend", false}, // JSON 中被转义为 \u003c
+		{"a&b", "a&b", false},
+	}
+
+	for _, tt := range tests {
+		c := NewFeishuChannel("", "", []string{tt.keyword}, nil)
+		payload, err := c.Format(&alert.AlertEvent{Type: alert.AlertTypeTrend, Symbol: "XAUUSD", Message: tt.message})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var card struct {
+			Card struct {
+				Elements []struct {
+					Tag      string `json:"tag"`
+					Elements []struct {
+						Content string `json:"content"`
+					} `json:"elements"`
+				} `json:"elements"`
+			} `json:"card"`
+		}
+		if err := json.Unmarshal(payload, &card); err != nil {
+			t.Fatal(err)
+		}
+		last := card.Card.Elements[len(card.Card.Elements)-1]
+		added := last.Tag == "note" && len(last.Elements) == 1 && last.Elements[0].Content == tt.keyword
+		if added != tt.added {
+			t.Errorf("keyword %q in message %q: note added %v, want %v", tt.keyword, tt.message, added, tt.added)
+		}
+	}
+}
+
+// unescapeJSON re-encodes a JSON body without the HTML escaping of encoding/json
+func unescapeJSON(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+// Redrive delivers the dead letters in the outbox directory again, each with one attempt
+// through its channel. Delivered entries are removed from the dead-letter file; failed ones
+// stay with the new error. ids selects entries, empty means all.
+// It fails with ErrOutboxLocked while a running notifier holds the outbox, since the
+// notifier may append dead letters while the file is being rewritten.
+func Redrive(ctx context.Context, cfg *config.NotifierConfig, ids []string) (sent, failed int, err error) {
+	if cfg == nil || cfg.OutboxDir == "" {
+		return 0, 0, errors.New("notifier.outbox_dir is not configured")
+	}
+	if _, err := os.Stat(cfg.OutboxDir); errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+
+	lock, err := lockDir(cfg.OutboxDir)
+	if err != nil {
+		return 0, 0, fmt.Errorf("lock %s: %w", cfg.OutboxDir, err)
+	}
+	defer lock.Close()
+
+	entries, err := ReadDeadLetters(cfg.OutboxDir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	channels := make(map[string]Channel)
+	for _, ch := range cfg.GetChannels() {
+		if channels[ch.Name], err = NewChannel(ch); err != nil {
+			return 0, 0, fmt.Errorf("channel %s: %w", ch.Name, err)
+		}
+	}
+
+	var remaining []*Entry
+	for _, e := range entries {
+		if len(ids) > 0 && !slices.Contains(ids, e.ID) {
+			remaining = append(remaining, e)
+			continue
+		}
+
+		if err := redrive(ctx, channels[e.Channel], e); err != nil {
+			e.Attempts++
+			e.Error = err.Error()
+			e.Time = time.Now()
+			remaining = append(remaining, e)
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	return sent, failed, WriteDeadLetters(cfg.OutboxDir, remaining)
+}
+
+func redrive(ctx context.Context, ch Channel, e *Entry) error {
+	if ch == nil {
+		return fmt.Errorf("unknown channel %s", e.Channel)
+	}
+
+	payload, err := e.format(ch)
+	if err != nil {
+		return err
+	}
+	return ch.Deliver(ctx, payload)
+}
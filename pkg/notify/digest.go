@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/logger"
+)
+
+// digestBuffer holds the low-severity alerts of a channel until its window ends,
+// then hands them to the sender as one digest entry
+type digestBuffer struct {
+	window     time.Duration
+	severities []string
+
+	mu      sync.Mutex
+	held    []*Entry
+	timer   *time.Timer
+	stopped bool
+}
+
+func newDigestBuffer(cfg *config.DigestConfig) *digestBuffer {
+	if cfg == nil {
+		return nil
+	}
+	return &digestBuffer{
+		window:     cfg.Window,
+		severities: cfg.GetSeverities(),
+	}
+}
+
+// accepts reports whether a is batched; Critical alerts and reports never are
+func (d *digestBuffer) accepts(a *alert.AlertEvent) bool {
+	return d != nil && a.Severity != alert.SeverityCritical && a.Type != alert.AlertTypeReport &&
+		slices.Contains(d.severities, string(a.Severity))
+}
+
+// hold buffers e; the first alert of a window starts the flush timer
+func (d *digestBuffer) hold(e *Entry, window time.Duration, flush func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return false
+	}
+
+	d.held = append(d.held, e)
+	if d.timer == nil {
+		d.timer = time.AfterFunc(window, flush)
+	}
+	return true
+}
+
+// take removes the buffered alerts. It runs fn with them while holding the lock,
+// so a concurrent stop waits until fn has queued the digest.
+func (d *digestBuffer) take(stop bool, fn func([]*Entry)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return
+	}
+	d.stopped = stop
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	held := d.held
+	d.held = nil
+	if len(held) > 0 {
+		fn(held)
+	}
+}
+
+// flushDigest queues the alerts buffered in d as one digest
+func (s *sender) flushDigest(d *digestBuffer, stop bool) {
+	d.take(stop, func(held []*Entry) {
+		events := make([]*alert.AlertEvent, len(held))
+		for i, e := range held {
+			events[i] = e.Event
+		}
+
+		digest := NewDigestEntry(s.cfg.Name, events)
+		if s.outbox != nil {
+			if err := s.outbox.Add(digest); err != nil {
+				logger.Errorf("[notifier:%s] failed to journal digest: %v", s.cfg.Name, err)
+			}
+			for _, e := range held {
+				if err := s.outbox.Done(e.ID); err != nil {
+					logger.Errorf("[notifier:%s] failed to journal alert %s: %v", s.cfg.Name, e.ID, err)
+				}
+			}
+		}
+
+		logger.Debugf("[notifier:%s] sending digest of %d alerts", s.cfg.Name, len(events))
+		_ = s.enqueue(digest)
+	})
+}
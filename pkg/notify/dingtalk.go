@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+)
+
+// DingTalkChannel posts markdown messages to a DingTalk custom robot webhook
+type DingTalkChannel struct {
+	url    string
+	client *http.Client
+}
+
+// Format renders the alert as a DingTalk markdown message
+func (c *DingTalkChannel) Format(a *alert.AlertEvent) ([]byte, error) {
+	// 钉钉 markdown 换行需要空行或行尾两个空格
+	text := fmt.Sprintf("### %s\n\n%s", a.Title(), strings.Join(markdownLines(a), "\n\n"))
+
+	return json.Marshal(map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]any{
+			"title": a.Title(),
+			"text":  text,
+		},
+	})
+}
+
+// FormatDigest renders the alerts as one markdown message listing each alert
+func (c *DingTalkChannel) FormatDigest(events []*alert.AlertEvent) ([]byte, error) {
+	lines := make([]string, len(events))
+	for i, a := range events {
+		lines[i] = "- " + digestLine(a)
+	}
+
+	return json.Marshal(map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]any{
+			"title": digestTitle(events),
+			"text":  fmt.Sprintf("### %s\n\n%s", digestTitle(events), strings.Join(lines, "\n")),
+		},
+	})
+}
+
+// Deliver posts the message and checks the errcode of the response
+func (c *DingTalkChannel) Deliver(ctx context.Context, payload []byte) error {
+	body, err := postJSON(ctx, c.client, c.url, payload)
+	if err != nil {
+		return err
+	}
+	return checkErrCode(body)
+}
+
+// checkErrCode parses the {"errcode": 0, "errmsg": "ok"} response of DingTalk and WeCom,
+// both answer HTTP 200 on failure
+func checkErrCode(body []byte) error {
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid response %q: %w", body, err)
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("errcode %d: %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+)
+
+// FeishuChannel posts interactive cards to a Feishu custom bot webhook.
+// With a secret every message carries a signed timestamp; with keywords every
+// message contains at least one of them, as required by the bot security settings.
+type FeishuChannel struct {
+	url      string
+	secret   string
+	keywords []string
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewFeishuChannel creates a channel for a Feishu bot webhook
+func NewFeishuChannel(url, secret string, keywords []string, client *http.Client) *FeishuChannel {
+	return &FeishuChannel{
+		url:      url,
+		secret:   secret,
+		keywords: keywords,
+		client:   client,
+		now:      time.Now,
+	}
+}
+
+// Format renders the alert as a Feishu card
+func (c *FeishuChannel) Format(a *alert.AlertEvent) ([]byte, error) {
+	return c.marshal(a.ToFeishuCard())
+}
+
+// FormatDigest renders the alerts as one card listing each alert
+func (c *FeishuChannel) FormatDigest(events []*alert.AlertEvent) ([]byte, error) {
+	lines := make([]string, len(events))
+	for i, a := range events {
+		lines[i] = "• " + digestLine(a)
+	}
+
+	card := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]interface{}{
+				"wide_screen_mode": true,
+			},
+			"header": map[string]interface{}{
+				"title": map[string]interface{}{
+					"tag":     "plain_text",
+					"content": digestTitle(events),
+				},
+				"template": "grey",
+			},
+			"elements": []map[string]interface{}{
+				{
+					"tag": "div",
+					"text": map[string]interface{}{
+						"tag":     "lark_md",
+						"content": strings.Join(lines, "\n"),
+					},
+				},
+			},
+		},
+	}
+	return c.marshal(card)
+}
+
+// marshal encodes the card, adding a keyword note when the card text contains none
+func (c *FeishuChannel) marshal(card map[string]interface{}) ([]byte, error) {
+	if len(c.keywords) == 0 {
+		return json.Marshal(card)
+	}
+
+	// 只检查展示的文本，JSON 的键名与 tag 不算，且文本未经 HTML 转义
+	var text strings.Builder
+	cardText(card["card"], &text)
+	for _, k := range c.keywords {
+		if strings.Contains(text.String(), k) {
+			return json.Marshal(card)
+		}
+	}
+
+	// 卡片中不含任何关键词时在底部追加，否则会被机器人拒绝
+	body := card["card"].(map[string]interface{})
+	body["elements"] = append(body["elements"].([]map[string]interface{}), map[string]interface{}{
+		"tag": "note",
+		"elements": []map[string]interface{}{
+			{"tag": "plain_text", "content": c.keywords[0]},
+		},
+	})
+	return json.Marshal(card)
+}
+
+// cardText collects the rendered text of a card: the content of the header title and of every element
+func cardText(v interface{}, text *strings.Builder) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if content, ok := child.(string); ok && k == "content" {
+				text.WriteString(content)
+				text.WriteByte('\n')
+				continue
+			}
+			cardText(child, text)
+		}
+	case []map[string]interface{}:
+		for _, child := range v {
+			cardText(child, text)
+		}
+	case []interface{}:
+		for _, child := range v {
+			cardText(child, text)
+		}
+	}
+}
+
+// Deliver signs the card when a secret is configured, posts it and checks the response code
+func (c *FeishuChannel) Deliver(ctx context.Context, payload []byte) error {
+	// 签名在投递时计算，重试时时间戳保持新鲜
+	if c.secret != "" {
+		var err error
+		if payload, err = c.sign(payload); err != nil {
+			return err
+		}
+	}
+
+	body, err := postJSON(ctx, c.client, c.url, payload)
+	if err != nil {
+		return err
+	}
+
+	// 飞书失败时同样返回 HTTP 200，需要检查 code
+	var resp struct {
+		Code          int    `json:"code"`
+		Msg           string `json:"msg"`
+		StatusCode    int    `json:"StatusCode"` // 旧版接口
+		StatusMessage string `json:"StatusMessage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid response %q: %w", body, err)
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("feishu code %d: %s", resp.Code, resp.Msg)
+	}
+	if resp.StatusCode != 0 {
+		return fmt.Errorf("feishu code %d: %s", resp.StatusCode, resp.StatusMessage)
+	}
+	return nil
+}
+
+// sign adds timestamp and sign to the payload.
+// sign = base64(HmacSHA256(key = timestamp + "\n" + secret, data = ""))
+func (c *FeishuChannel) sign(payload []byte) ([]byte, error) {
+	msg := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return nil, err
+	}
+
+	ts := strconv.FormatInt(c.now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(ts+"\n"+c.secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	msg["timestamp"], _ = json.Marshal(ts)
+	msg["sign"], _ = json.Marshal(sign)
+	return json.Marshal(msg)
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package notify
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lockDir only creates the lock file; outbox directories are not locked on this platform
+func lockDir(dir string) (*os.File, error) {
+	return os.OpenFile(filepath.Join(dir, lockFile), os.O_CREATE|os.O_RDWR, 0644)
+}
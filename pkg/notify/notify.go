@@ -1,15 +1,8 @@
 package notify
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
 	"errors"
-	"math"
-	"math/rand"
-	"net/http"
-	"sync"
-	"sync/atomic"
+	"fmt"
 	"time"
 
 	"github.com/wangpf09/golddog/pkg/alert"
@@ -17,165 +10,141 @@ import (
 	"github.com/wangpf09/golddog/pkg/logger"
 )
 
-// Notifier 负责告警分发
+// Notifier 负责告警分发：按路由规则把告警投递到一个或多个通道
 type Notifier struct {
-	cfg    *config.NotifierConfig
-	client *http.Client
-	queue  chan *alert.AlertEvent
-	wg     sync.WaitGroup
-	ctx    context.Context
-	cancel context.CancelFunc
-	closed atomic.Bool // 原子操作标记是否已关闭，防止向已关闭的 channel 发送数据
+	channels map[string]*sender
+	order    []string // 通道的配置顺序，未配置路由时全部投递
+	routes   []route
+	outbox   *Outbox
+	quiet    *quietGate // 静默时段与维护窗口，位于投递之前
 }
 
-//type Config struct {
-//	WebhookURL string
-//	Workers    int
-//	QueueSize  int
-//
-//	MaxRetries int
-//	Backoff    time.Duration
-//	Backoff time.Duration
-//	Timeout    time.Duration
-//}
-
 func NewNotifier(cfg *config.NotifierConfig) (*Notifier, error) {
-	if cfg.WebhookURL == "" {
-		return nil, errors.New("webhook url required")
+	if cfg == nil {
+		return nil, errors.New("notifier config required")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-
-	ctx, cancel := context.WithCancel(context.Background())
 
 	n := &Notifier{
-		cfg:    cfg,
-		client: &http.Client{Timeout: cfg.Timeout},
-		queue:  make(chan *alert.AlertEvent, cfg.QueueSize),
-		ctx:    ctx,
-		cancel: cancel,
+		channels: make(map[string]*sender),
+		quiet:    newQuietGate(cfg.Quiet),
 	}
 
-	n.startWorkers()
-	return n, nil
-}
-
-func (n *Notifier) startWorkers() {
-	for i := 0; i < n.cfg.Workers; i++ {
-		n.wg.Add(1)
-		go n.worker(i)
+	var pending []*Entry
+	if cfg.OutboxDir != "" {
+		var err error
+		if n.outbox, pending, err = OpenOutbox(cfg.OutboxDir); err != nil {
+			return nil, fmt.Errorf("open outbox: %w", err)
+		}
 	}
-}
 
-// Send 非阻塞发送告警
-func (n *Notifier) Send(a *alert.AlertEvent) error {
-	// 检查是否已关闭，避免 panic
-	if n.closed.Load() {
-		return errors.New("notifier is closed")
+	for _, ch := range cfg.GetChannels() {
+		s, err := newSender(ch, n.outbox)
+		if err != nil {
+			n.Close()
+			return nil, fmt.Errorf("channel %s: %w", ch.Name, err)
+		}
+		n.channels[ch.Name] = s
+		n.order = append(n.order, ch.Name)
 	}
-
-	select {
-	case n.queue <- a:
-		return nil
-	default:
-		return errors.New("alert queue full")
+	for _, r := range cfg.Routes {
+		n.routes = append(n.routes, newRoute(r))
 	}
-}
 
-func (n *Notifier) worker(id int) {
-	defer n.wg.Done()
-	logger.Debugf("[notifier] worker-%d started", id)
-
-	// 使用 range 循环，这样 channel 关闭时会自动退出，
-	// 并且会处理完 channel 中剩余的数据（优雅退出）
-	for a := range n.queue {
-		if err := n.handleAlert(a); err != nil {
-			logger.Errorf("[notifier] worker-%d failed to send %s: %v", id, a.Symbol, err)
+	// 重新投递上次退出前未送达的告警
+	backlog := make(map[string][]*Entry)
+	for _, e := range pending {
+		if _, ok := n.channels[e.Channel]; !ok {
+			if err := n.outbox.Dead(e, fmt.Errorf("unknown channel %s", e.Channel)); err != nil {
+				logger.Errorf("[notifier] failed to dead-letter alert %s: %v", e.ID, err)
+			}
+			continue
 		}
+		backlog[e.Channel] = append(backlog[e.Channel], e)
 	}
+	for name, entries := range backlog {
+		n.channels[name].resume(entries)
+	}
+
+	return n, nil
 }
 
-func (n *Notifier) handleAlert(a *alert.AlertEvent) error {
-	// 优化1：只序列化一次
-	payload, err := json.Marshal(a.ToFeishuCard())
-	if err != nil {
-		return err
+// Send 非阻塞发送告警到匹配路由的所有通道
+func (n *Notifier) Send(a *alert.AlertEvent) error {
+	targets := n.match(a)
+	if len(targets) == 0 {
+		logger.Debugf("[notifier] no route for %s %s alert %s, dropped", a.Severity, a.Type, a.Symbol)
+		return nil
 	}
 
-	var lastErr error
-	// 循环次数 = 1次首次尝试 + MaxRetries次重试
-	for i := 0; i <= n.cfg.MaxRetries; i++ {
-		// 检查上下文是否已取消（快速退出）
-		if n.ctx.Err() != nil {
-			return n.ctx.Err()
-		}
+	q := n.quiet.check(a, time.Now())
+	if q != nil && q.action == config.QuietMute {
+		n.mute(a, q.reason)
+		return nil
+	}
 
-		if err := n.doRequest(payload); err == nil {
-			return nil
+	var errs []error
+	for _, name := range targets {
+		var err error
+		if q != nil {
+			err = n.channels[name].deferUntil(a, q.until)
 		} else {
-			lastErr = err
+			err = n.channels[name].send(a)
 		}
-
-		// 如果不是最后一次尝试，则等待
-		if i < n.cfg.MaxRetries {
-			wait := n.calcBackoff(i + 1)
-			logger.Warnf("[notifier] retry %d/%d in %v (%s): %v", i+1, n.cfg.MaxRetries, wait, a.Symbol, lastErr)
-
-			select {
-			case <-time.After(wait):
-			case <-n.ctx.Done(): // 支持重试等待期间被取消
-				return n.ctx.Err()
-			}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
 		}
 	}
-	return lastErr
-}
-
-func (n *Notifier) doRequest(body []byte) error {
-	// 优化2：请求绑定 Context，以便 Close() 时能取消正在进行的 HTTP 请求
-	req, err := http.NewRequestWithContext(n.ctx, "POST", n.cfg.WebhookURL, bytes.NewReader(body))
-	if err != nil {
-		return err
+	if q != nil {
+		logger.Infof("[notifier] deferred %s %s alert %s (%s)", a.Severity, a.Type, a.Symbol, q.reason)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return errors.Join(errs...)
+}
 
-	resp, err := n.client.Do(req)
-	if err != nil {
-		return err
+// mute records an alert held back by quiet hours without sending it
+func (n *Notifier) mute(a *alert.AlertEvent, reason string) {
+	logger.Infof("[notifier] muted %s (%s)", a.String(), reason)
+	if n.outbox == nil {
+		return
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return errors.New(resp.Status)
+	if err := n.outbox.Muted(a, reason); err != nil {
+		logger.Errorf("[notifier] failed to record muted alert: %v", err)
 	}
-	return nil
 }
 
-// calcBackoff 计算带抖动的指数退避
-func (n *Notifier) calcBackoff(attempt int) time.Duration {
-	// 2^(attempt-1) * base
-	factor := math.Pow(2, float64(attempt-1))
-	backoff := float64(n.cfg.Backoff) * factor
-
-	if backoff > float64(n.cfg.Backoff) {
-		backoff = float64(n.cfg.Backoff)
+// match returns the channels of every matching route, each once
+func (n *Notifier) match(a *alert.AlertEvent) []string {
+	if len(n.routes) == 0 {
+		return n.order
 	}
 
-	// 优化3：简化抖动逻辑，+/- 10%
-	jitter := (rand.Float64()*0.2 - 0.1) * backoff
-	return time.Duration(backoff + jitter)
+	var targets []string
+	seen := make(map[string]bool)
+	for _, r := range n.routes {
+		if !r.match(a) {
+			continue
+		}
+		for _, name := range r.channels {
+			if !seen[name] {
+				seen[name] = true
+				targets = append(targets, name)
+			}
+		}
+	}
+	return targets
 }
 
-// Close 优雅关闭
+// Close 优雅关闭所有通道，等待队列中的告警发送完毕
 func (n *Notifier) Close() {
-	if !n.closed.CompareAndSwap(false, true) {
-		return // 已经关闭
+	for _, name := range n.order {
+		n.channels[name].close()
 	}
 
-	// 1. 关闭 channel，让 worker 处理完剩余数据后退出 range 循环
-	close(n.queue)
-
-	// 2. 等待所有 worker 处理完积压数据
-	n.wg.Wait()
-
-	// 3. 此时所有 worker 已退出，取消上下文以清理可能的资源
-	n.cancel()
+	if n.outbox != nil {
+		if err := n.outbox.Close(); err != nil {
+			logger.Errorf("[notifier] failed to close outbox: %v", err)
+		}
+	}
 }
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+// hook records the card titles posted to a test webhook
+type hook struct {
+	mu     sync.Mutex
+	titles []string
+}
+
+func (h *hook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var card struct {
+		Card struct {
+			Header struct {
+				Title struct {
+					Content string `json:"content"`
+				} `json:"title"`
+			} `json:"header"`
+		} `json:"card"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&card)
+
+	h.mu.Lock()
+	h.titles = append(h.titles, card.Card.Header.Title.Content)
+	h.mu.Unlock()
+
+	_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+}
+
+func (h *hook) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.titles)
+}
+
+func TestNotifierRoutes(t *testing.T) {
+	oncall, lowNoise := &hook{}, &hook{}
+	oncallSrv, lowNoiseSrv := httptest.NewServer(oncall), httptest.NewServer(lowNoise)
+	defer oncallSrv.Close()
+	defer lowNoiseSrv.Close()
+
+	n, err := NewNotifier(&config.NotifierConfig{
+		Timeout: time.Second,
+		Channels: []config.ChannelConfig{
+			{Name: "oncall", WebhookURL: oncallSrv.URL},
+			{Name: "low-noise", WebhookURL: lowNoiseSrv.URL},
+		},
+		Routes: []config.RouteConfig{
+			{Severities: []string{"Critical"}, Channels: []string{"oncall"}},
+			{Types: []string{"Trend"}, Severities: []string{"Info"}, Channels: []string{"low-noise"}},
+			{Symbols: []string{"XAGUSD"}, Channels: []string{"oncall", "low-noise"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(typ alert.AlertType, sev alert.AlertSeverity, symbol string) {
+		if err := n.Send(&alert.AlertEvent{Type: typ, Severity: sev, Symbol: symbol, Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send(alert.AlertTypeJump, alert.SeverityCritical, "XAUUSD")      // oncall
+	send(alert.AlertTypeTrend, alert.SeverityInfo, "XAUUSD")         // low-noise
+	send(alert.AlertTypeVolatility, alert.SeverityWarning, "XAUUSD") // no route
+	send(alert.AlertTypeJump, alert.SeverityCritical, "XAGUSD")      // both, oncall once
+	n.Close()
+
+	if got := oncall.count(); got != 2 {
+		t.Errorf("oncall received %d alerts, want 2", got)
+	}
+	if got := lowNoise.count(); got != 2 {
+		t.Errorf("low-noise received %d alerts, want 2", got)
+	}
+}
+
+func TestNotifierConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.NotifierConfig
+		ok   bool
+	}{
+		{"legacy webhook", config.NotifierConfig{WebhookURL: "http://x"}, true},
+		{"no channel", config.NotifierConfig{}, false},
+		{"unknown route channel", config.NotifierConfig{
+			WebhookURL: "http://x",
+			Routes:     []config.RouteConfig{{Channels: []string{"oncall"}}},
+		}, false},
+		{"duplicate channel", config.NotifierConfig{
+			Channels: []config.ChannelConfig{{Name: "a", WebhookURL: "http://x"}, {Name: "a", WebhookURL: "http://y"}},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: err=%v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestNotifierDigest(t *testing.T) {
+	h := &hook{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	n, err := NewNotifier(&config.NotifierConfig{
+		WebhookURL: srv.URL,
+		Timeout:    time.Second,
+		Digest:     &config.DigestConfig{Window: 100 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		_ = n.Send(&alert.AlertEvent{Type: alert.AlertTypeTrend, Severity: alert.SeverityInfo, Symbol: "XAUUSD", Timestamp: now})
+	}
+	_ = n.Send(&alert.AlertEvent{Type: alert.AlertTypeJump, Severity: alert.SeverityCritical, Symbol: "XAUUSD", Timestamp: now})
+
+	// Critical 立即发送，Info 等待窗口结束
+	time.Sleep(50 * time.Millisecond)
+	if got := h.count(); got != 1 {
+		t.Fatalf("received %d messages before the digest window ended, want 1", got)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	n.Close()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.titles) != 2 || !strings.HasPrefix(h.titles[1], "📋 Alert digest: 3 alerts") {
+		t.Errorf("titles = %q, want the jump alert and a digest of 3", h.titles)
+	}
+}
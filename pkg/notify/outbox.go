@@ -0,0 +1,343 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/logger"
+)
+
+const (
+	outboxFile     = "outbox.jsonl"
+	deadLetterFile = "deadletter.jsonl"
+	mutedFile      = "muted.jsonl"
+	lockFile       = "outbox.lock"
+
+	// compactAfter 已完成记录达到该数量时压缩日志
+	compactAfter = 1000
+)
+
+// ErrOutboxLocked is returned when another process holds the outbox directory
+var ErrOutboxLocked = errors.New("outbox is in use by another process")
+
+// Entry is one alert, or one digest of alerts, queued for delivery to one channel
+type Entry struct {
+	ID       string              `json:"id"`
+	Channel  string              `json:"channel"`
+	Event    *alert.AlertEvent   `json:"event,omitempty"`
+	Digest   []*alert.AlertEvent `json:"digest,omitempty"` // Alerts of a digest entry
+	Held     bool                `json:"held,omitempty"`   // Buffered for the next digest
+	Until    time.Time           `json:"until,omitzero"`   // End of the quiet window deferring the entry
+	Attempts int                 `json:"attempts,omitempty"`
+	Error    string              `json:"error,omitempty"` // Last delivery error of a dead letter
+	Time     time.Time           `json:"time"`            // When the entry was queued or dead-lettered
+}
+
+// outboxRecord is one line of the append-only outbox journal
+type outboxRecord struct {
+	Op    string `json:"op"` // add or done
+	ID    string `json:"id,omitempty"`
+	Entry *Entry `json:"entry,omitempty"`
+}
+
+// Outbox persists queued alerts so they survive restarts.
+// Every entry is journaled before it is queued and marked done once delivered or
+// dead-lettered; entries that exhaust their retries go to the dead-letter file.
+// The journal is compacted when the outbox is opened and whenever compactAfter entries
+// have finished since. The directory is locked while the outbox is open, so only one
+// process writes it.
+type Outbox struct {
+	dir          string
+	mu           sync.Mutex
+	journal      *os.File
+	lock         *os.File
+	done         int // Done records written since the last compaction
+	compactAfter int
+}
+
+// OpenOutbox opens the outbox in dir and returns the entries that were never delivered
+func OpenOutbox(dir string) (*Outbox, []*Entry, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lock %s: %w", dir, err)
+	}
+
+	pending, f, err := compactJournal(filepath.Join(dir, outboxFile))
+	if err != nil {
+		lock.Close()
+		return nil, nil, err
+	}
+
+	if len(pending) > 0 {
+		logger.Infof("[notifier] outbox has %d undelivered alerts, redelivering", len(pending))
+	}
+	return &Outbox{dir: dir, journal: f, lock: lock, compactAfter: compactAfter}, pending, nil
+}
+
+// compactJournal rewrites the journal at path with only the unfinished entries and
+// returns them with the journal opened for appending
+func compactJournal(path string) ([]*Entry, *os.File, error) {
+	pending, err := readJournal(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writeJSONLines(path, pending, func(e *Entry) any { return outboxRecord{Op: "add", Entry: e} }); err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pending, f, nil
+}
+
+// NewEntry creates an entry for delivering e to channel
+func NewEntry(channel string, e *alert.AlertEvent) *Entry {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return &Entry{
+		ID:      hex.EncodeToString(id),
+		Channel: channel,
+		Event:   e,
+		Time:    time.Now(),
+	}
+}
+
+// NewDigestEntry creates an entry delivering events to channel as one digest
+func NewDigestEntry(channel string, events []*alert.AlertEvent) *Entry {
+	e := NewEntry(channel, nil)
+	e.Digest = events
+	return e
+}
+
+// Describe summarizes the entry for logs and listings
+func (e *Entry) Describe() string {
+	if e.Event == nil {
+		return fmt.Sprintf("digest of %d alerts", len(e.Digest))
+	}
+	return fmt.Sprintf("%s %s %s", e.Event.Severity, e.Event.Type, e.Event.Symbol)
+}
+
+// format renders the entry with the channel formatter
+func (e *Entry) format(ch Channel) ([]byte, error) {
+	if e.Event == nil {
+		return ch.FormatDigest(e.Digest)
+	}
+	return ch.Format(e.Event)
+}
+
+// Add journals a new entry
+func (o *Outbox) Add(e *Entry) error {
+	return o.append(outboxRecord{Op: "add", Entry: e})
+}
+
+// Done marks an entry as finished
+func (o *Outbox) Done(id string) error {
+	return o.append(outboxRecord{Op: "done", ID: id})
+}
+
+// Dead moves an entry to the dead-letter file
+func (o *Outbox) Dead(e *Entry, cause error) error {
+	dead := *e
+	dead.Error = cause.Error()
+	dead.Time = time.Now()
+
+	o.mu.Lock()
+	err := appendJSONLine(filepath.Join(o.dir, deadLetterFile), &dead)
+	o.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return o.Done(e.ID)
+}
+
+// Muted records an alert that was muted by quiet hours or a maintenance window
+func (o *Outbox) Muted(a *alert.AlertEvent, reason string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return appendJSONLine(filepath.Join(o.dir, mutedFile), struct {
+		Time   time.Time         `json:"time"`
+		Reason string            `json:"reason"`
+		Event  *alert.AlertEvent `json:"event"`
+	}{time.Now(), reason, a})
+}
+
+func (o *Outbox) append(r outboxRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, err := o.journal.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	if r.Op == "done" {
+		o.done++
+		if o.done >= o.compactAfter {
+			o.compact()
+		}
+	}
+	return nil
+}
+
+// compact rewrites the journal without the finished entries; o.mu must be held.
+// A failed compaction keeps the current journal and is retried after the next done record.
+func (o *Outbox) compact() {
+	pending, f, err := compactJournal(filepath.Join(o.dir, outboxFile))
+	if err != nil {
+		logger.Warnf("[notifier] failed to compact the outbox: %v", err)
+		return
+	}
+
+	if err := o.journal.Close(); err != nil {
+		logger.Warnf("[notifier] failed to close the outbox journal: %v", err)
+	}
+	logger.Debugf("[notifier] compacted the outbox after %d finished entries, %d pending", o.done, len(pending))
+	o.journal, o.done = f, 0
+}
+
+// Close closes the journal and releases the directory; undelivered entries are
+// redelivered on the next open
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	err := o.journal.Close()
+	o.lock.Close()
+	return err
+}
+
+// readJournal replays the journal and returns the entries without a done record, in order
+func readJournal(path string) ([]*Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var order []string
+	entries := make(map[string]*Entry)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var r outboxRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// 进程崩溃时最后一行可能不完整
+			logger.Warnf("[notifier] skipping corrupt outbox line %d: %v", line, err)
+			continue
+		}
+		switch {
+		case r.Op == "add" && r.Entry != nil:
+			if _, ok := entries[r.Entry.ID]; !ok {
+				order = append(order, r.Entry.ID)
+			}
+			entries[r.Entry.ID] = r.Entry
+		case r.Op == "done":
+			delete(entries, r.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []*Entry
+	for _, id := range order {
+		if e, ok := entries[id]; ok {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+// ReadDeadLetters returns the dead letters stored in dir
+func ReadDeadLetters(dir string) ([]*Entry, error) {
+	f, err := os.Open(filepath.Join(dir, deadLetterFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", deadLetterFile, line, err)
+		}
+		entries = append(entries, &e)
+	}
+	return entries, scanner.Err()
+}
+
+// WriteDeadLetters replaces the dead letters stored in dir
+func WriteDeadLetters(dir string, entries []*Entry) error {
+	return writeJSONLines(filepath.Join(dir, deadLetterFile), entries, func(e *Entry) any { return e })
+}
+
+func appendJSONLine(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeJSONLines atomically replaces path with one JSON line per entry
+func writeJSONLines(path string, entries []*Entry, record func(*Entry) any) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(record(e)); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+func TestOutboxPending(t *testing.T) {
+	dir := t.TempDir()
+
+	o, pending, err := OpenOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("new outbox has %d pending entries", len(pending))
+	}
+
+	a := NewEntry("oncall", &alert.AlertEvent{Type: alert.AlertTypeJump, Symbol: "XAUUSD"})
+	b := NewEntry("oncall", &alert.AlertEvent{Type: alert.AlertTypeTrend, Symbol: "XAUUSD"})
+	for _, e := range []*Entry{a, b} {
+		if err := o.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Done(a.ID); err != nil {
+		t.Fatal(err)
+	}
+	o.Close()
+
+	// 重新打开两次：第二次读取的是压缩后的日志
+	for i := 0; i < 2; i++ {
+		o, pending, err = OpenOutbox(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.Close()
+
+		if len(pending) != 1 || pending[0].ID != b.ID || pending[0].Event.Type != alert.AlertTypeTrend {
+			t.Fatalf("open %d: pending = %+v, want entry %s", i, pending, b.ID)
+		}
+	}
+}
+
+func TestOutboxCompact(t *testing.T) {
+	dir := t.TempDir()
+	o, _, err := OpenOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	o.compactAfter = 3
+
+	// 每完成 3 条压缩一次，日志只保留未完成的条目
+	path := filepath.Join(dir, outboxFile)
+	var last *Entry
+	for i := range 7 {
+		last = NewEntry("oncall", &alert.AlertEvent{Type: alert.AlertTypeJump, Symbol: "XAUUSD"})
+		if err := o.Add(last); err != nil {
+			t.Fatal(err)
+		}
+		if i < 6 {
+			if err := o.Done(last.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 1 {
+		t.Errorf("journal has %d lines after compaction, want only the pending entry:\n%s", n, data)
+	}
+
+	pending, err := readJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != last.ID {
+		t.Errorf("pending = %+v, want entry %s", pending, last.ID)
+	}
+}
+
+func TestDeadLetterRedrive(t *testing.T) {
+	var up atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.NotifierConfig{
+		WebhookURL: srv.URL,
+		Timeout:    time.Second,
+		OutboxDir:  t.TempDir(),
+	}
+
+	n, err := NewNotifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Send(&alert.AlertEvent{Type: alert.AlertTypeJump, Symbol: "XAUUSD", Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	n.Close()
+
+	dead, err := ReadDeadLetters(cfg.OutboxDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].Channel != config.DefaultChannel || dead[0].Attempts != 1 || dead[0].Error == "" {
+		t.Fatalf("dead letters = %+v, want one failed default entry", dead)
+	}
+
+	up.Store(true)
+	sent, failed, err := Redrive(context.Background(), cfg, nil)
+	if err != nil || sent != 1 || failed != 0 {
+		t.Fatalf("redrive: sent=%d failed=%d err=%v", sent, failed, err)
+	}
+
+	if dead, _ = ReadDeadLetters(cfg.OutboxDir); len(dead) != 0 {
+		t.Errorf("%d dead letters left after redrive", len(dead))
+	}
+
+	// 死信已从 outbox 中移除，重启后不会再次投递
+	o, pending, err := OpenOutbox(cfg.OutboxDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Close()
+	if len(pending) != 0 {
+		t.Errorf("%d entries pending after dead-lettering", len(pending))
+	}
+}
+
+func TestOutboxLocked(t *testing.T) {
+	cfg := &config.NotifierConfig{WebhookURL: "http://127.0.0.1:0", OutboxDir: t.TempDir()}
+
+	n, err := NewNotifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 守护进程运行时不能重投死信
+	if _, _, err := Redrive(context.Background(), cfg, nil); !errors.Is(err, ErrOutboxLocked) {
+		t.Errorf("redrive while open: err = %v, want %v", err, ErrOutboxLocked)
+	}
+	if _, _, err := OpenOutbox(cfg.OutboxDir); !errors.Is(err, ErrOutboxLocked) {
+		t.Errorf("second open: err = %v, want %v", err, ErrOutboxLocked)
+	}
+
+	n.Close()
+	if _, _, err := Redrive(context.Background(), cfg, nil); err != nil {
+		t.Errorf("redrive after close: %v", err)
+	}
+}
+
+func TestOutboxBacklogExceedsQueue(t *testing.T) {
+	var sent atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		sent.Add(1)
+		_, _ = w.Write([]byte(`{"code":0}`))
+	}))
+	defer srv.Close()
+
+	cfg := &config.NotifierConfig{
+		WebhookURL: srv.URL,
+		Timeout:    time.Second,
+		QueueSize:  1,
+		Workers:    1,
+		OutboxDir:  t.TempDir(),
+	}
+
+	const backlog = 5
+	o, _, err := OpenOutbox(cfg.OutboxDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < backlog; i++ {
+		if err := o.Add(NewEntry(config.DefaultChannel, &alert.AlertEvent{Type: alert.AlertTypeJump, Symbol: "XAUUSD"})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o.Close()
+
+	n, err := NewNotifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); sent.Load() < backlog && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	n.Close()
+
+	if got := sent.Load(); got != backlog {
+		t.Errorf("sent %d of %d pending alerts", got, backlog)
+	}
+	if dead, _ := ReadDeadLetters(cfg.OutboxDir); len(dead) != 0 {
+		t.Errorf("%d pending alerts dead-lettered, want 0", len(dead))
+	}
+}
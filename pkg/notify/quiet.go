@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+// quietGate decides whether an alert is held back by quiet hours or a maintenance window
+type quietGate struct {
+	loc         *time.Location
+	below       alert.AlertSeverity
+	action      string
+	hours       []quietHours
+	maintenance []config.MaintenanceWindow
+}
+
+type quietHours struct {
+	days       map[time.Weekday]bool // Day of the start, nil means every day
+	start, end time.Duration
+}
+
+// quietMatch describes the window an alert falls into
+type quietMatch struct {
+	action string
+	until  time.Time // End of the window
+	reason string
+}
+
+func newQuietGate(cfg *config.QuietConfig) *quietGate {
+	if cfg == nil {
+		return nil
+	}
+
+	g := &quietGate{
+		loc:         cfg.GetLocation(),
+		below:       alert.AlertSeverity(cfg.GetBelow()),
+		action:      cfg.GetAction(),
+		maintenance: cfg.Maintenance,
+	}
+	for _, h := range cfg.Hours {
+		qh := quietHours{}
+		qh.start, _ = config.ParseTimeOfDay(h.Start)
+		qh.end, _ = config.ParseQuietEnd(h.End)
+		if len(h.Days) > 0 {
+			qh.days = make(map[time.Weekday]bool)
+			for _, d := range h.Days {
+				qh.days[config.Weekdays[d]] = true
+			}
+		}
+		g.hours = append(g.hours, qh)
+	}
+	return g
+}
+
+// check returns the window holding back a at now, nil when a should be sent
+func (g *quietGate) check(a *alert.AlertEvent, now time.Time) *quietMatch {
+	if g == nil {
+		return nil
+	}
+
+	for _, m := range g.maintenance {
+		if now.Before(m.Start) || !now.Before(m.End) {
+			continue
+		}
+		below := g.below
+		if m.Below != "" {
+			below = alert.AlertSeverity(m.Below)
+		}
+		if a.Severity.Rank() >= below.Rank() {
+			continue
+		}
+		action := g.action
+		if m.Action != "" {
+			action = m.Action
+		}
+		return &quietMatch{action: action, until: m.End, reason: "maintenance: " + m.Reason}
+	}
+
+	if a.Severity.Rank() >= g.below.Rank() {
+		return nil
+	}
+	for _, h := range g.hours {
+		if until, ok := h.contains(now.In(g.loc)); ok {
+			return &quietMatch{action: g.action, until: until, reason: fmt.Sprintf("quiet hours until %s", until.Format("15:04"))}
+		}
+	}
+	return nil
+}
+
+// contains reports whether t falls into a window starting today or, wrapping past midnight, yesterday
+func (h quietHours) contains(t time.Time) (time.Time, bool) {
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	for _, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+		if h.days != nil && !h.days[day.Weekday()] {
+			continue
+		}
+		start, end := config.AtTimeOfDay(day, h.start), config.AtTimeOfDay(day, h.end)
+		if h.end < h.start {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+func TestQuietGate(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	g := newQuietGate(&config.QuietConfig{
+		Timezone: "Asia/Shanghai",
+		Below:    "Warning",
+		Action:   config.QuietDefer,
+		Hours: []config.QuietHours{
+			{Start: "23:00", End: "07:00"},
+			{Days: []string{"Sat"}, Start: "12:00", End: "24:00"},
+		},
+		Maintenance: []config.MaintenanceWindow{{
+			Start:  time.Date(2026, 1, 7, 14, 0, 0, 0, loc),
+			End:    time.Date(2026, 1, 7, 15, 0, 0, 0, loc),
+			Reason: "upgrade",
+			Below:  "Critical",
+			Action: config.QuietMute,
+		}},
+	})
+
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 1, day, hour, min, 0, 0, loc) }
+
+	tests := []struct {
+		name     string
+		severity alert.AlertSeverity
+		now      time.Time
+		action   string // empty means sent
+		until    time.Time
+	}{
+		{"daytime", alert.SeverityInfo, at(5, 10, 0), "", time.Time{}},
+		{"night before midnight", alert.SeverityInfo, at(5, 23, 30), config.QuietDefer, at(6, 7, 0)},
+		{"night after midnight", alert.SeverityInfo, at(6, 3, 0), config.QuietDefer, at(6, 7, 0)},
+		{"end is exclusive", alert.SeverityInfo, at(6, 7, 0), "", time.Time{}},
+		{"warning is not below warning", alert.SeverityWarning, at(6, 3, 0), "", time.Time{}},
+		{"saturday afternoon", alert.SeverityInfo, at(10, 13, 0), config.QuietDefer, at(11, 0, 0)},
+		{"friday afternoon", alert.SeverityInfo, at(9, 13, 0), "", time.Time{}},
+		{"maintenance mutes warnings", alert.SeverityWarning, at(7, 14, 30), config.QuietMute, at(7, 15, 0)},
+		{"maintenance keeps critical", alert.SeverityCritical, at(7, 14, 30), "", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		q := g.check(&alert.AlertEvent{Severity: tt.severity}, tt.now)
+		switch {
+		case tt.action == "" && q != nil:
+			t.Errorf("%s: held back (%s), want sent", tt.name, q.reason)
+		case tt.action != "" && q == nil:
+			t.Errorf("%s: sent, want %s", tt.name, tt.action)
+		case q != nil && (q.action != tt.action || !q.until.Equal(tt.until)):
+			t.Errorf("%s: %s until %v, want %s until %v", tt.name, q.action, q.until, tt.action, tt.until)
+		}
+	}
+}
+
+func TestQuietHoursDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// 2026-11-01 夏令时结束，当天有 25 小时
+	h := quietHours{start: time.Hour, end: 6 * time.Hour}
+	until, ok := h.contains(time.Date(2026, 11, 1, 5, 30, 0, 0, loc))
+	if want := time.Date(2026, 11, 1, 6, 0, 0, 0, loc); !ok || !until.Equal(want) {
+		t.Errorf("05:30 on fall-back day: until %v (%v), want %v", until, ok, want)
+	}
+}
+
+func TestQuietDeferRestart(t *testing.T) {
+	h := &hook{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	cfg := &config.NotifierConfig{
+		WebhookURL: srv.URL,
+		Timeout:    time.Second,
+		OutboxDir:  t.TempDir(),
+		Quiet: &config.QuietConfig{
+			Action: config.QuietDefer,
+			Maintenance: []config.MaintenanceWindow{{
+				Start: time.Now().Add(-time.Minute),
+				End:   time.Now().Add(300 * time.Millisecond),
+			}},
+		},
+	}
+
+	n, err := NewNotifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = n.Send(&alert.AlertEvent{Type: alert.AlertTypeTrend, Severity: alert.SeverityInfo, Symbol: "XAUUSD", Timestamp: time.Now()})
+	n.Close()
+	if got := h.count(); got != 0 {
+		t.Fatalf("received %d messages during the quiet window, want 0", got)
+	}
+
+	// 重启后继续延迟到维护窗口结束
+	n, err = NewNotifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	if got := h.count(); got != 0 {
+		t.Fatalf("received %d messages after restart, want the alert held", got)
+	}
+	for deadline := time.Now().Add(2 * time.Second); h.count() == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.titles) != 1 || !strings.HasPrefix(h.titles[0], "📋 Alert digest: 1 alerts") {
+		t.Errorf("titles = %q, want a digest of the deferred alert", h.titles)
+	}
+}
@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"slices"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+// route matches alerts on type, severity and symbol; an empty matcher matches anything
+type route struct {
+	types      []string
+	severities []string
+	symbols    []string
+	channels   []string
+}
+
+func newRoute(cfg config.RouteConfig) route {
+	return route{
+		types:      cfg.Types,
+		severities: cfg.Severities,
+		symbols:    cfg.Symbols,
+		channels:   cfg.Channels,
+	}
+}
+
+func (r route) match(a *alert.AlertEvent) bool {
+	return matchAny(r.types, string(a.Type)) &&
+		matchAny(r.severities, string(a.Severity)) &&
+		matchAny(r.symbols, a.Symbol)
+}
+
+func matchAny(values []string, v string) bool {
+	return len(values) == 0 || slices.Contains(values, v)
+}
@@ -0,0 +1,276 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/logger"
+)
+
+// sender 负责单个通道的投递：独立的队列、worker 与重试策略
+type sender struct {
+	cfg     config.ChannelConfig
+	channel Channel
+	outbox  *Outbox       // 可选，持久化待投递告警
+	digest  *digestBuffer // 可选，低级别告警合并发送
+	quiet   *digestBuffer // 静默时段延迟的告警，时段结束时合并发送
+	queue   chan *Entry
+	wg      sync.WaitGroup
+	backlog sync.WaitGroup // 重启后补投积压告警的 goroutine
+	stop    chan struct{}  // 关闭时通知补投停止
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closed  atomic.Bool // 原子操作标记是否已关闭，防止向已关闭的 channel 发送数据
+}
+
+func newSender(cfg config.ChannelConfig, outbox *Outbox) (*sender, error) {
+	channel, err := NewChannel(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &sender{
+		cfg:     cfg,
+		channel: channel,
+		outbox:  outbox,
+		digest:  newDigestBuffer(cfg.Digest),
+		quiet:   &digestBuffer{},
+		queue:   make(chan *Entry, cfg.QueueSize),
+		stop:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker(i)
+	}
+	return s, nil
+}
+
+// send 非阻塞入队，启用 outbox 时先写入日志
+func (s *sender) send(a *alert.AlertEvent) error {
+	// 检查是否已关闭，避免 panic
+	if s.closed.Load() {
+		return errors.New("channel is closed")
+	}
+
+	e := NewEntry(s.cfg.Name, a)
+	e.Held = s.digest.accepts(a)
+	if s.outbox != nil {
+		if err := s.outbox.Add(e); err != nil {
+			logger.Errorf("[notifier:%s] failed to journal alert: %v", s.cfg.Name, err)
+		}
+	}
+	return s.deliver(e)
+}
+
+// deliver 将条目放入摘要缓冲或发送队列
+func (s *sender) deliver(e *Entry) error {
+	if s.hold(e) {
+		return nil
+	}
+	return s.enqueue(e)
+}
+
+// hold 将标记为摘要的条目放入摘要缓冲，未放入时清除标记
+func (s *sender) hold(e *Entry) bool {
+	if e.Held && s.digest != nil && s.digest.hold(e, s.digest.window, func() { s.flushDigest(s.digest, false) }) {
+		return true
+	}
+	e.Held = false
+	return false
+}
+
+// resume redelivers the entries left in the outbox by the last run. Unlike send it waits
+// for queue space, so a backlog larger than the queue is fed in as the workers drain it.
+// Entries not queued before close stay in the outbox for the next start.
+func (s *sender) resume(entries []*Entry) {
+	var backlog []*Entry
+	for _, e := range entries {
+		// 静默时段延迟的告警继续等到原定时间
+		if e.Until.After(time.Now()) && s.quiet.hold(e, time.Until(e.Until), func() { s.flushDigest(s.quiet, false) }) {
+			continue
+		}
+		if !s.hold(e) {
+			backlog = append(backlog, e)
+		}
+	}
+	if len(backlog) == 0 {
+		return
+	}
+
+	s.backlog.Add(1)
+	go func() {
+		defer s.backlog.Done()
+		for _, e := range backlog {
+			select {
+			case s.queue <- e:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// deferUntil holds a until the quiet window ends, then sends the held alerts as one digest
+func (s *sender) deferUntil(a *alert.AlertEvent, until time.Time) error {
+	if s.closed.Load() {
+		return errors.New("channel is closed")
+	}
+
+	// 记录截止时间，重启后继续延迟
+	e := NewEntry(s.cfg.Name, a)
+	e.Until = until
+	if s.outbox != nil {
+		if err := s.outbox.Add(e); err != nil {
+			logger.Errorf("[notifier:%s] failed to journal alert: %v", s.cfg.Name, err)
+		}
+	}
+
+	if s.quiet.hold(e, time.Until(until), func() { s.flushDigest(s.quiet, false) }) {
+		return nil
+	}
+	return s.enqueue(e)
+}
+
+// enqueue 队列已满时转入死信，避免告警丢失
+func (s *sender) enqueue(e *Entry) error {
+	select {
+	case s.queue <- e:
+		return nil
+	default:
+		err := errors.New("alert queue full")
+		s.dead(e, err)
+		return err
+	}
+}
+
+// dead moves an undeliverable entry to the dead-letter file
+func (s *sender) dead(e *Entry, cause error) {
+	if s.outbox == nil {
+		return
+	}
+	if err := s.outbox.Dead(e, cause); err != nil {
+		logger.Errorf("[notifier:%s] failed to dead-letter alert %s: %v", s.cfg.Name, e.ID, err)
+		return
+	}
+	logger.Warnf("[notifier:%s] alert %s moved to dead letters: %v", s.cfg.Name, e.ID, cause)
+}
+
+func (s *sender) worker(id int) {
+	defer s.wg.Done()
+	logger.Debugf("[notifier:%s] worker-%d started", s.cfg.Name, id)
+
+	// 使用 range 循环，这样 channel 关闭时会自动退出，
+	// 并且会处理完 channel 中剩余的数据（优雅退出）
+	for e := range s.queue {
+		err := s.handleAlert(e)
+		switch {
+		case err == nil:
+			if s.outbox != nil {
+				if err := s.outbox.Done(e.ID); err != nil {
+					logger.Errorf("[notifier:%s] failed to journal alert %s: %v", s.cfg.Name, e.ID, err)
+				}
+			}
+		case s.ctx.Err() != nil:
+			// 关闭时未投递的告警保留在 outbox 中，重启后重新投递
+			logger.Warnf("[notifier:%s] worker-%d stopped before sending %s: %v", s.cfg.Name, id, e.Describe(), err)
+		default:
+			logger.Errorf("[notifier:%s] worker-%d failed to send %s: %v", s.cfg.Name, id, e.Describe(), err)
+			s.dead(e, err)
+		}
+	}
+}
+
+func (s *sender) handleAlert(e *Entry) error {
+	// 只格式化一次
+	payload, err := e.format(s.channel)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	// 循环次数 = 1次首次尝试 + MaxRetries次重试
+	for i := 0; i <= s.cfg.MaxRetries; i++ {
+		// 检查上下文是否已取消（快速退出）
+		if s.ctx.Err() != nil {
+			return s.ctx.Err()
+		}
+
+		e.Attempts++
+		if err := s.channel.Deliver(s.ctx, payload); err == nil {
+			return nil
+		} else {
+			lastErr = err
+		}
+
+		// 如果不是最后一次尝试，则等待
+		if i < s.cfg.MaxRetries {
+			wait := s.calcBackoff(i + 1)
+			logger.Warnf("[notifier:%s] retry %d/%d in %v (%s): %v", s.cfg.Name, i+1, s.cfg.MaxRetries, wait, e.Describe(), lastErr)
+
+			select {
+			case <-time.After(wait):
+			case <-s.ctx.Done(): // 支持重试等待期间被取消
+				return s.ctx.Err()
+			}
+		}
+	}
+	return lastErr
+}
+
+// calcBackoff 计算带抖动的指数退避，不超过 MaxBackoff
+func (s *sender) calcBackoff(attempt int) time.Duration {
+	// 2^(attempt-1) * base
+	factor := math.Pow(2, float64(attempt-1))
+	backoff := float64(s.cfg.InitialBackoff) * factor
+
+	if backoff > float64(s.cfg.MaxBackoff) {
+		backoff = float64(s.cfg.MaxBackoff)
+	}
+
+	// 简化抖动逻辑，+/- 10%
+	jitter := (rand.Float64()*0.2 - 0.1) * backoff
+	return time.Duration(backoff + jitter)
+}
+
+// close 优雅关闭
+func (s *sender) close() {
+	if !s.closed.CompareAndSwap(false, true) {
+		return // 已经关闭
+	}
+
+	// 0. 立即发送缓冲中的摘要
+	if s.digest != nil {
+		s.flushDigest(s.digest, true)
+	}
+	if s.outbox != nil {
+		// 延迟的告警保留在 outbox 中，重启后继续等到静默时段结束
+		s.quiet.take(true, func([]*Entry) {})
+	} else {
+		s.flushDigest(s.quiet, true)
+	}
+
+	// 停止补投积压告警，之后才能关闭队列
+	close(s.stop)
+	s.backlog.Wait()
+
+	// 1. 关闭 channel，让 worker 处理完剩余数据后退出 range 循环
+	close(s.queue)
+
+	// 2. 等待所有 worker 处理完积压数据
+	s.wg.Wait()
+
+	// 3. 此时所有 worker 已退出，取消上下文以清理可能的资源
+	s.cancel()
+}
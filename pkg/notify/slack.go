@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+)
+
+// SlackChannel posts Block Kit messages to a Slack incoming webhook
+type SlackChannel struct {
+	url    string
+	client *http.Client
+}
+
+// slackEscape escapes the control characters of Slack mrkdwn
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Format renders the alert as Block Kit blocks, with the title as notification fallback
+func (c *SlackChannel) Format(a *alert.AlertEvent) ([]byte, error) {
+	field := func(label, value string) map[string]any {
+		return map[string]any{"type": "mrkdwn", "text": fmt.Sprintf("*%s*\n%s", label, slackEscape.Replace(value))}
+	}
+
+	fields := []map[string]any{
+		field("Severity", string(a.Severity)),
+		field("Time", a.Timestamp.Format("2006-01-02 15:04:05")),
+	}
+	if a.IsResolved() {
+		fields = append(fields,
+			field("Duration", a.Duration().Round(time.Second).String()),
+			field("Price", fmt.Sprintf("%.2f → %.2f (%+.2f)", a.StartPrice, a.Price, a.Price-a.StartPrice)),
+		)
+	}
+
+	msg := map[string]any{
+		"text": a.Title(),
+		"blocks": []map[string]any{
+			{
+				"type": "header",
+				"text": map[string]any{"type": "plain_text", "text": a.Title()},
+			},
+			{
+				"type":   "section",
+				"fields": fields,
+			},
+			{
+				"type": "section",
+				"text": field("Message", a.Message),
+			},
+			{
+				"type": "context",
+				"elements": []map[string]any{
+					{"type": "mrkdwn", "text": "Alert ID: " + a.ID()},
+				},
+			},
+		},
+	}
+	return json.Marshal(msg)
+}
+
+// FormatDigest renders the alerts as one message listing each alert
+func (c *SlackChannel) FormatDigest(events []*alert.AlertEvent) ([]byte, error) {
+	lines := make([]string, len(events))
+	for i, a := range events {
+		lines[i] = "• " + slackEscape.Replace(digestLine(a))
+	}
+
+	return json.Marshal(map[string]any{
+		"text": digestTitle(events),
+		"blocks": []map[string]any{
+			{
+				"type": "header",
+				"text": map[string]any{"type": "plain_text", "text": digestTitle(events)},
+			},
+			{
+				"type": "section",
+				"text": map[string]any{"type": "mrkdwn", "text": strings.Join(lines, "\n")},
+			},
+		},
+	})
+}
+
+// Deliver posts the message; Slack answers errors with a non-2xx status
+func (c *SlackChannel) Deliver(ctx context.Context, payload []byte) error {
+	_, err := postJSON(ctx, c.client, c.url, payload)
+	return err
+}
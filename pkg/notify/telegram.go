@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+)
+
+// telegramAPI is the default Bot API base URL
+const telegramAPI = "https://api.telegram.org"
+
+// TelegramChannel sends HTML messages through the Telegram Bot API sendMessage method
+type TelegramChannel struct {
+	url    string
+	chatID string
+	client *http.Client
+}
+
+// NewTelegramChannel creates a channel for the bot token and chat; apiURL overrides the Bot API base URL
+func NewTelegramChannel(apiURL, token, chatID string, client *http.Client) *TelegramChannel {
+	if apiURL == "" {
+		apiURL = telegramAPI
+	}
+	return &TelegramChannel{
+		url:    fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(apiURL, "/"), token),
+		chatID: chatID,
+		client: client,
+	}
+}
+
+// Format renders the alert as a sendMessage request with HTML parse mode
+func (c *TelegramChannel) Format(a *alert.AlertEvent) ([]byte, error) {
+	esc := html.EscapeString
+
+	lines := []string{
+		"<b>" + esc(a.Title()) + "</b>",
+		"",
+		"<b>Severity</b>: " + esc(string(a.Severity)),
+		"<b>Time</b>: " + a.Timestamp.Format("2006-01-02 15:04:05"),
+	}
+	if a.IsResolved() {
+		lines = append(lines,
+			"<b>Duration</b>: "+a.Duration().Round(time.Second).String(),
+			fmt.Sprintf("<b>Price</b>: %.2f → %.2f (%+.2f)", a.StartPrice, a.Price, a.Price-a.StartPrice),
+		)
+	}
+	lines = append(lines,
+		"<b>Message</b>: "+esc(a.Message),
+		"<i>Alert ID: "+esc(a.ID())+"</i>",
+	)
+
+	return json.Marshal(map[string]any{
+		"chat_id":    c.chatID,
+		"text":       strings.Join(lines, "\n"),
+		"parse_mode": "HTML",
+	})
+}
+
+// FormatDigest renders the alerts as one message listing each alert
+func (c *TelegramChannel) FormatDigest(events []*alert.AlertEvent) ([]byte, error) {
+	lines := []string{"<b>" + html.EscapeString(digestTitle(events)) + "</b>", ""}
+	for _, a := range events {
+		lines = append(lines, "• "+html.EscapeString(digestLine(a)))
+	}
+
+	return json.Marshal(map[string]any{
+		"chat_id":    c.chatID,
+		"text":       strings.Join(lines, "\n"),
+		"parse_mode": "HTML",
+	})
+}
+
+// Deliver calls sendMessage and checks the ok flag of the response
+func (c *TelegramChannel) Deliver(ctx context.Context, payload []byte) error {
+	body, err := postJSON(ctx, c.client, c.url, payload)
+	if err != nil {
+		// 请求 URL 中包含 bot token，不写入日志
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			return fmt.Errorf("telegram sendMessage: %w", ue.Err)
+		}
+		return err
+	}
+
+	var resp struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid response %q: %w", body, err)
+	}
+	if !resp.OK {
+		return fmt.Errorf("telegram: %s", resp.Description)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/wangpf09/golddog/pkg/alert"
+)
+
+// WeComChannel posts markdown messages to a WeCom (企业微信) group robot webhook
+type WeComChannel struct {
+	url    string
+	client *http.Client
+}
+
+// Format renders the alert as a WeCom markdown message
+func (c *WeComChannel) Format(a *alert.AlertEvent) ([]byte, error) {
+	// 企业微信 markdown 仅支持 info/comment/warning 三种字体颜色
+	color := "comment"
+	switch {
+	case a.IsResolved():
+		color = "info"
+	case a.Severity == alert.SeverityCritical, a.Severity == alert.SeverityWarning:
+		color = "warning"
+	}
+
+	content := fmt.Sprintf("### <font color=\"%s\">%s</font>\n%s", color, a.Title(), strings.Join(markdownLines(a), "\n"))
+
+	return json.Marshal(map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]any{
+			"content": content,
+		},
+	})
+}
+
+// FormatDigest renders the alerts as one markdown message listing each alert
+func (c *WeComChannel) FormatDigest(events []*alert.AlertEvent) ([]byte, error) {
+	lines := make([]string, len(events))
+	for i, a := range events {
+		lines[i] = "> " + digestLine(a)
+	}
+
+	// 企业微信 markdown 内容上限 4096 字节，超出部分截断
+	content := fmt.Sprintf("### %s\n%s", digestTitle(events), strings.Join(lines, "\n"))
+	if len(content) > 4096 {
+		content = strings.ToValidUTF8(content[:4090], "") + "\n..."
+	}
+
+	return json.Marshal(map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]any{
+			"content": content,
+		},
+	})
+}
+
+// Deliver posts the message and checks the errcode of the response
+func (c *WeComChannel) Deliver(ctx context.Context, payload []byte) error {
+	body, err := postJSON(ctx, c.client, c.url, payload)
+	if err != nil {
+		return err
+	}
+	return checkErrCode(body)
+}
@@ -0,0 +1,136 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokDuration
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	dur  time.Duration
+	pos  int
+}
+
+// operators ordered so that two-character operators match first
+var operators = []string{"<=", ">=", "==", "!=", "&&", "||", "<", ">", "+", "-", "*", "/", "!"}
+
+// keywords are spelled-out aliases of the logical operators
+var keywords = map[string]string{"and": "&&", "or": "||", "not": "!"}
+
+var durationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+}
+
+// lex splits an expression into tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+
+		case c == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+
+		case unicode.IsDigit(c) || c == '.':
+			tok, next, err := lexNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_') {
+				i++
+			}
+			word := src[start:i]
+			if op, ok := keywords[strings.ToLower(word)]; ok {
+				tokens = append(tokens, token{kind: tokOp, text: op, pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokIdent, text: word, pos: start})
+			}
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// lexNumber reads a number, a percentage like 2% or a duration like 30m
+func lexNumber(src string, start int) (token, int, error) {
+	i := start
+	for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+		i++
+	}
+
+	v, err := strconv.ParseFloat(src[start:i], 64)
+	if err != nil {
+		return token{}, 0, fmt.Errorf("invalid number %q at %d", src[start:i], start)
+	}
+
+	if i < len(src) && src[i] == '%' {
+		return token{kind: tokNumber, text: src[start : i+1], num: v / 100, pos: start}, i + 1, nil
+	}
+
+	unitStart := i
+	for i < len(src) && unicode.IsLetter(rune(src[i])) {
+		i++
+	}
+	if unitStart == i {
+		return token{kind: tokNumber, text: src[start:i], num: v, pos: start}, i, nil
+	}
+
+	unit, ok := durationUnits[src[unitStart:i]]
+	if !ok {
+		return token{}, 0, fmt.Errorf("unknown duration unit %q at %d", src[unitStart:i], unitStart)
+	}
+	return token{kind: tokDuration, text: src[start:i], dur: time.Duration(v * float64(unit)), pos: start}, i, nil
+}
@@ -0,0 +1,189 @@
+package rule
+
+import (
+	"fmt"
+)
+
+// binding powers of the binary operators
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6,
+}
+
+type parser struct {
+	tokens  []token
+	pos     int
+	isField func(name string) bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t := p.next()
+	if t.kind != kind {
+		return fmt.Errorf("expected %q at %d, got %q", text, t.pos, t.text)
+	}
+	return nil
+}
+
+// parseExpr parses a binary expression whose operators bind tighter than minPrec
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokOp || !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseExpr(prec)
+		if err != nil {
+			return nil, err
+		}
+
+		if left, err = newBinary(t, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if t.kind == tokOp && (t.text == "-" || t.text == "!") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if (t.text == "!") != x.boolean() {
+			return nil, fmt.Errorf("operator %s at %d has an operand of the wrong type", t.text, t.pos)
+		}
+		return &unaryNode{op: t.text, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokNumber:
+		return &numberNode{v: t.num}, nil
+
+	case tokLParen:
+		x, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(tokRParen, ")")
+
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.parseCall(t)
+		}
+		if !p.isField(t.text) {
+			return nil, fmt.Errorf("unknown field %q at %d", t.text, t.pos)
+		}
+		return &fieldNode{name: t.text}, nil
+
+	case tokDuration:
+		return nil, fmt.Errorf("duration %s at %d is only allowed as a function argument", t.text, t.pos)
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+}
+
+// parseCall parses fn(args...) and type-checks the arguments
+func (p *parser) parseCall(fn token) (node, error) {
+	p.next() // (
+
+	var args []token
+	var exprs []node
+	for p.peek().kind != tokRParen {
+		if len(args) > 0 {
+			if err := p.expect(tokComma, ","); err != nil {
+				return nil, err
+			}
+		}
+
+		t := p.peek()
+		if t.kind == tokDuration {
+			p.next()
+			args = append(args, t)
+			exprs = append(exprs, nil)
+			continue
+		}
+
+		x, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, t)
+		exprs = append(exprs, x)
+	}
+	p.next() // )
+
+	switch fn.text {
+	case "min", "max", "mean", "change", "pct_change":
+		if len(args) != 2 || args[1].kind != tokDuration {
+			return nil, fmt.Errorf("%s at %d expects (field, duration)", fn.text, fn.pos)
+		}
+		field, ok := exprs[0].(*fieldNode)
+		if !ok {
+			return nil, fmt.Errorf("%s at %d expects a field as first argument", fn.text, fn.pos)
+		}
+		if args[1].dur <= 0 {
+			return nil, fmt.Errorf("%s at %d expects a positive duration", fn.text, fn.pos)
+		}
+		return &aggNode{fn: fn.text, field: field.name, window: args[1].dur}, nil
+
+	case "abs":
+		if len(exprs) != 1 || exprs[0] == nil || exprs[0].boolean() {
+			return nil, fmt.Errorf("abs at %d expects one numeric argument", fn.pos)
+		}
+		return &absNode{x: exprs[0]}, nil
+
+	case "crosses", "crosses_above", "crosses_below":
+		if len(exprs) != 2 || exprs[0] == nil || exprs[1] == nil || exprs[0].boolean() || exprs[1].boolean() {
+			return nil, fmt.Errorf("%s at %d expects two numeric arguments", fn.text, fn.pos)
+		}
+		return &crossNode{fn: fn.text, a: exprs[0], b: exprs[1]}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown function %q at %d", fn.text, fn.pos)
+	}
+}
+
+func newBinary(op token, l, r node) (node, error) {
+	switch op.text {
+	case "&&", "||":
+		if !l.boolean() || !r.boolean() {
+			return nil, fmt.Errorf("operator %s at %d expects conditions on both sides", op.text, op.pos)
+		}
+	default:
+		if l.boolean() || r.boolean() {
+			return nil, fmt.Errorf("operator %s at %d expects numbers on both sides", op.text, op.pos)
+		}
+	}
+	return &binaryNode{op: op.text, l: l, r: r}, nil
+}
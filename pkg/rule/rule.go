@@ -0,0 +1,313 @@
+// Package rule implements the expression language of custom alert rules.
+//
+// An expression is a condition over the fields of the latest sample:
+//
+//	price > 2700
+//	pct_change(price_cny, 30m) <= -2%
+//	crosses(price, open) and abs(price_change) > 1
+//
+// Numbers may carry a % suffix (2% == 0.02). Arithmetic (+ - * /), comparisons
+// (< <= > >= == !=) and logic (and/&&, or/||, not/!) are supported, plus:
+//
+//	min(field, d) max(field, d) mean(field, d)  rolling aggregate over the last d
+//	change(field, d)                            current value minus the value d ago
+//	pct_change(field, d)                        change relative to the value d ago
+//	abs(x)
+//	crosses_above(a, b) crosses_below(a, b) crosses(a, b)
+//
+// change and pct_change have no value, so the condition is false, until the data
+// reaches back d. Durations use the units s, m, h and d, e.g. 90s, 30m, 1h.
+package rule
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Env supplies field values to an expression
+type Env interface {
+	// Field returns the current value of a field
+	Field(name string) (float64, bool)
+	// Series returns the values of a field within the last window, oldest first,
+	// ending with the current value
+	Series(name string, window time.Duration) []float64
+	// At returns the value of a field at the latest sample at or before ago before the
+	// current one; false when the data does not reach back that far
+	At(name string, ago time.Duration) (float64, bool)
+}
+
+// Program is a compiled expression. It keeps state for the crosses functions,
+// so each evaluated series (e.g. each symbol) needs its own Program.
+type Program struct {
+	src  string
+	root node
+}
+
+// Compile parses src; isField reports which identifiers are valid fields
+func Compile(src string, isField func(name string) bool) (*Program, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, isField: isField}
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	if !root.boolean() {
+		return nil, fmt.Errorf("expression must be a condition, e.g. price > 2700")
+	}
+
+	return &Program{src: src, root: root}, nil
+}
+
+// String returns the source expression
+func (p *Program) String() string {
+	return p.src
+}
+
+// Eval reports whether the condition holds; it is false while there is not enough data
+func (p *Program) Eval(env Env) bool {
+	v, ok := p.root.eval(env)
+	return ok && v != 0
+}
+
+// Edge reports whether the condition marks an event rather than a state: a crossing, or a
+// conjunction with one. Such a condition holds for single evaluations only.
+func (p *Program) Edge() bool {
+	return isEdge(p.root)
+}
+
+func isEdge(n node) bool {
+	switch n := n.(type) {
+	case *crossNode:
+		return true
+	case *binaryNode:
+		switch n.op {
+		case "&&":
+			return isEdge(n.l) || isEdge(n.r)
+		case "||":
+			return isEdge(n.l) && isEdge(n.r)
+		}
+	}
+	return false
+}
+
+// node is an expression tree node; booleans evaluate to 1 or 0
+type node interface {
+	eval(env Env) (float64, bool)
+	boolean() bool
+}
+
+type numberNode struct {
+	v float64
+}
+
+func (n *numberNode) eval(Env) (float64, bool) { return n.v, true }
+func (n *numberNode) boolean() bool            { return false }
+
+type fieldNode struct {
+	name string
+}
+
+func (n *fieldNode) eval(env Env) (float64, bool) { return env.Field(n.name) }
+func (n *fieldNode) boolean() bool                { return false }
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) eval(env Env) (float64, bool) {
+	v, ok := n.x.eval(env)
+	if !ok {
+		return 0, false
+	}
+	if n.op == "!" {
+		return b2f(v == 0), true
+	}
+	return -v, true
+}
+
+func (n *unaryNode) boolean() bool { return n.op == "!" }
+
+type binaryNode struct {
+	op   string
+	l, r node
+}
+
+func (n *binaryNode) eval(env Env) (float64, bool) {
+	// 两侧都求值，保证 crosses 等有状态的函数每次都能更新
+	l, lok := n.l.eval(env)
+	r, rok := n.r.eval(env)
+
+	switch n.op {
+	case "&&":
+		return b2f(lok && rok && l != 0 && r != 0), true
+	case "||":
+		return b2f((lok && l != 0) || (rok && r != 0)), true
+	}
+
+	if !lok || !rok {
+		return 0, false
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, true
+	case "-":
+		return l - r, true
+	case "*":
+		return l * r, true
+	case "/":
+		if r == 0 {
+			return 0, false
+		}
+		return l / r, true
+	case "<":
+		return b2f(l < r), true
+	case "<=":
+		return b2f(l <= r), true
+	case ">":
+		return b2f(l > r), true
+	case ">=":
+		return b2f(l >= r), true
+	case "==":
+		return b2f(l == r), true
+	case "!=":
+		return b2f(l != r), true
+	}
+	return 0, false
+}
+
+func (n *binaryNode) boolean() bool {
+	switch n.op {
+	case "+", "-", "*", "/":
+		return false
+	}
+	return true
+}
+
+// aggNode is a rolling aggregate of a field over a time window
+type aggNode struct {
+	fn     string
+	field  string
+	window time.Duration
+}
+
+func (n *aggNode) eval(env Env) (float64, bool) {
+	if n.fn == "change" || n.fn == "pct_change" {
+		return n.change(env)
+	}
+
+	values := env.Series(n.field, n.window)
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	switch n.fn {
+	case "min":
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Min(m, v)
+		}
+		return m, true
+	case "max":
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Max(m, v)
+		}
+		return m, true
+	case "mean":
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values)), true
+	}
+	return 0, false
+}
+
+// change compares the current value with the value a full window ago;
+// a partial window would understate the move, so it has no value until then
+func (n *aggNode) change(env Env) (float64, bool) {
+	last, ok := env.Field(n.field)
+	if !ok {
+		return 0, false
+	}
+	first, ok := env.At(n.field, n.window)
+	if !ok {
+		return 0, false
+	}
+
+	if n.fn == "change" {
+		return last - first, true
+	}
+	if first == 0 {
+		return 0, false
+	}
+	return (last - first) / first, true
+}
+
+func (n *aggNode) boolean() bool { return false }
+
+type absNode struct {
+	x node
+}
+
+func (n *absNode) eval(env Env) (float64, bool) {
+	v, ok := n.x.eval(env)
+	return math.Abs(v), ok
+}
+
+func (n *absNode) boolean() bool { return false }
+
+// crossNode is true on the evaluation where a moves from one side of b to the other
+type crossNode struct {
+	fn   string
+	a, b node
+
+	prev    float64 // a - b at the previous evaluation
+	hasPrev bool
+}
+
+func (n *crossNode) eval(env Env) (float64, bool) {
+	a, aok := n.a.eval(env)
+	b, bok := n.b.eval(env)
+	if !aok || !bok {
+		return 0, false
+	}
+
+	diff := a - b
+	prev, hasPrev := n.prev, n.hasPrev
+	n.prev, n.hasPrev = diff, true
+	if !hasPrev {
+		return 0, true
+	}
+
+	up := prev < 0 && diff >= 0
+	down := prev > 0 && diff <= 0
+
+	switch n.fn {
+	case "crosses_above":
+		return b2f(up), true
+	case "crosses_below":
+		return b2f(down), true
+	default:
+		return b2f(up || down), true
+	}
+}
+
+func (n *crossNode) boolean() bool { return true }
+
+func b2f(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
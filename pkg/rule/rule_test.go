@@ -0,0 +1,139 @@
+package rule
+
+import (
+	"testing"
+	"time"
+)
+
+// testEnv serves fields from a fixed series sampled once per minute
+type testEnv struct {
+	series map[string][]float64
+}
+
+func (e *testEnv) Field(name string) (float64, bool) {
+	s, ok := e.series[name]
+	if !ok || len(s) == 0 {
+		return 0, false
+	}
+	return s[len(s)-1], true
+}
+
+func (e *testEnv) Series(name string, window time.Duration) []float64 {
+	s := e.series[name]
+	n := int(window/time.Minute) + 1
+	if n > len(s) {
+		n = len(s)
+	}
+	return s[len(s)-n:]
+}
+
+func (e *testEnv) At(name string, ago time.Duration) (float64, bool) {
+	s := e.series[name]
+	i := len(s) - 1 - int(ago/time.Minute)
+	if i < 0 {
+		return 0, false
+	}
+	return s[i], true
+}
+
+func isField(name string) bool {
+	return name == "price" || name == "open" || name == "price_cny"
+}
+
+func TestEval(t *testing.T) {
+	env := &testEnv{series: map[string][]float64{
+		"price":     {2690, 2695, 2705},
+		"open":      {2700, 2700, 2700},
+		"price_cny": {600, 590, 580},
+	}}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"price > 2700", true},
+		{"price >= 2705 and open == 2700", true},
+		{"price > 2800 or not (open < 2700)", true},
+		{"-price < -2700", true},
+		{"(price - open) / open > 0.1%", true},
+		{"max(price, 2m) == 2705 && min(price, 2m) == 2690", true},
+		{"mean(price, 1m) == 2700", true},
+		{"change(price, 2m) == 15", true},
+		{"pct_change(price_cny, 2m) <= -2%", true},
+		{"pct_change(price_cny, 30m) <= -2%", false}, // 数据不足 30 分钟
+		{"change(price, 3m) > 0", false},
+		{"abs(change(price_cny, 1m)) > 5", true},
+		{"price < 2700", false},
+	}
+
+	for _, tt := range tests {
+		p, err := Compile(tt.expr, isField)
+		if err != nil {
+			t.Errorf("Compile(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got := p.Eval(env); got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{
+		"price",            // not a condition
+		"volume > 1",       // unknown field
+		"price > 2700 and", // incomplete
+		"min(price) > 1",   // missing window
+		"min(2700, 1m) > 1",
+		"price > 30m",
+		"(price > 1) + 1 > 0",
+		"foo(price) > 1",
+		"price > 1 $",
+	}
+
+	for _, expr := range tests {
+		if _, err := Compile(expr, isField); err == nil {
+			t.Errorf("Compile(%q) expected error", expr)
+		}
+	}
+}
+
+func TestCrosses(t *testing.T) {
+	env := &testEnv{series: map[string][]float64{"open": {2700}}}
+	above, _ := Compile("crosses_above(price, open)", isField)
+	either, _ := Compile("crosses(price, open)", isField)
+
+	prices := []float64{2690, 2695, 2705, 2710, 2698}
+	wantAbove := []bool{false, false, true, false, false}
+	wantAny := []bool{false, false, true, false, true}
+
+	for i, p := range prices {
+		env.series["price"] = []float64{p}
+		if got := above.Eval(env); got != wantAbove[i] {
+			t.Errorf("crosses_above at %v = %v, want %v", p, got, wantAbove[i])
+		}
+		if got := either.Eval(env); got != wantAny[i] {
+			t.Errorf("crosses at %v = %v, want %v", p, got, wantAny[i])
+		}
+	}
+}
+
+func TestEdge(t *testing.T) {
+	tests := map[string]bool{
+		"crosses_above(price, open)":                            true,
+		"crosses(price, open) && price > 2700":                  true,
+		"crosses_above(price, open) || crosses_below(price, 1)": true,
+		"crosses(price, open) || price > 2700":                  false,
+		"!crosses(price, open)":                                 false,
+		"price > 2700":                                          false,
+	}
+	for src, want := range tests {
+		p, err := Compile(src, isField)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if got := p.Edge(); got != want {
+			t.Errorf("%s: Edge() = %v, want %v", src, got, want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package source
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// csvHeader is the column layout of recorded CSV files
+var csvHeader = []string{
+	"symbol", "last_price", "last_price_cny", "open", "high", "low",
+	"volume", "turnover", "timestamp", "status", "fx_rate", "unit",
+}
+
+// legacyCSVFields are the column counts of files recorded before fx_rate and unit were added
+var legacyCSVFields = []int{10, 11}
+
+// snapshotEncoder writes snapshots in one file format
+type snapshotEncoder interface {
+	Encode(snap NormalizedSnapshot) error
+	Flush() error
+}
+
+func newSnapshotEncoder(format string, w io.Writer) (snapshotEncoder, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlEncoder{w: bufio.NewWriter(w)}, nil
+	case FormatCSV:
+		enc := &csvEncoder{w: csv.NewWriter(w)}
+		if err := enc.w.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return enc, nil
+	default:
+		return nil, fmt.Errorf("unknown snapshot format: %s", format)
+	}
+}
+
+type jsonlEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *jsonlEncoder) Encode(snap NormalizedSnapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(data); err != nil {
+		return err
+	}
+	return e.w.WriteByte('\n')
+}
+
+func (e *jsonlEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(snap NormalizedSnapshot) error {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return e.w.Write([]string{
+		snap.Symbol,
+		f(snap.LastPrice),
+		f(snap.LastPriceCNY),
+		f(snap.Open),
+		f(snap.High),
+		f(snap.Low),
+		f(snap.Volume),
+		f(snap.Turnover),
+		snap.Timestamp.Format(time.RFC3339Nano),
+		strconv.Itoa(snap.Status),
+		f(snap.FXRate),
+		snap.Unit,
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ReadSnapshots reads recorded snapshots from path and calls fn for each one in file order.
+// path may be a single file, a directory or a glob pattern; multiple files are read in
+// lexical order, which matches the timestamped names produced by Recorder.
+// Reading stops at the first error returned by fn.
+func ReadSnapshots(path string, fn func(NormalizedSnapshot) error) error {
+	files, err := expandPath(path)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := readSnapshotFile(file, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandPath resolves a file, directory or glob pattern to a sorted list of files
+func expandPath(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && !info.IsDir():
+		return []string{path}, nil
+	case err == nil && info.IsDir():
+		path = filepath.Join(path, "*")
+	}
+
+	matches, err := filepath.Glob(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot path %s: %w", path, err)
+	}
+
+	var files []string
+	for _, m := range matches {
+		if formatOf(m) != "" {
+			files = append(files, m)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no snapshot files found at %s", path)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// formatOf detects the file format from the file extension
+func formatOf(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".jsonl", ".json":
+		return FormatJSONL
+	case ".csv":
+		return FormatCSV
+	default:
+		return ""
+	}
+}
+
+func readSnapshotFile(file string, fn func(NormalizedSnapshot) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot file: %w", err)
+	}
+	defer f.Close()
+
+	switch formatOf(file) {
+	case FormatCSV:
+		return readCSV(file, f, fn)
+	default:
+		return readJSONL(file, f, fn)
+	}
+}
+
+func readJSONL(file string, r io.Reader, fn func(NormalizedSnapshot) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var snap NormalizedSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snap); err != nil {
+			return fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+		snap.backfillFXRate()
+		if err := fn(snap); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func readCSV(file string, r io.Reader, fn func(NormalizedSnapshot) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	line := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		line++
+		if len(record) != len(csvHeader) && !slices.Contains(legacyCSVFields, len(record)) {
+			return fmt.Errorf("%s:%d: got %d fields, want %d", file, line, len(record), len(csvHeader))
+		}
+		if record[0] == csvHeader[0] {
+			continue // header
+		}
+
+		snap, err := parseCSVRecord(record)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+		if err := fn(snap); err != nil {
+			return err
+		}
+	}
+}
+
+func parseCSVRecord(record []string) (NormalizedSnapshot, error) {
+	snap := NormalizedSnapshot{Symbol: record[0]}
+
+	fields := []*float64{
+		&snap.LastPrice, &snap.LastPriceCNY, &snap.Open, &snap.High,
+		&snap.Low, &snap.Volume, &snap.Turnover,
+	}
+	for i, dst := range fields {
+		v, err := parseFloat(record[i+1], csvHeader[i+1])
+		if err != nil {
+			return snap, err
+		}
+		*dst = v
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, record[8])
+	if err != nil {
+		return snap, fmt.Errorf("failed to parse timestamp: %w", err)
+	}
+	snap.Timestamp = ts
+
+	snap.Status, err = strconv.Atoi(record[9])
+	if err != nil {
+		return snap, fmt.Errorf("failed to parse status: %w", err)
+	}
+
+	if len(record) > 10 {
+		if snap.FXRate, err = parseFloat(record[10], csvHeader[10]); err != nil {
+			return snap, err
+		}
+	}
+	if len(record) > 11 {
+		snap.Unit = record[11]
+	}
+	snap.backfillFXRate()
+
+	return snap, nil
+}
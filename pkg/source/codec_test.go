@@ -0,0 +1,168 @@
+package source
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+func testSnapshots() []NormalizedSnapshot {
+	t0 := time.Date(2026, 3, 2, 9, 30, 0, 123456789, time.UTC)
+	return []NormalizedSnapshot{
+		{Symbol: "XAUUSD", LastPrice: 2650.35, LastPriceCNY: 589.7012, Unit: "USD/oz", FXRate: 6.92, Open: 2640.1, High: 2655, Low: 2638.25, Volume: 1200, Turnover: 3180000.5, Timestamp: t0},
+		{Symbol: "AU9999", LastPrice: 612.5, Unit: "CNY/g", Open: 610, High: 613, Low: 609.8, Volume: 35, Timestamp: t0.Add(time.Second), Status: 1},
+	}
+}
+
+// writeSnapshots writes snaps to path in the format of its extension
+func writeSnapshots(t *testing.T, path string, snaps ...NormalizedSnapshot) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	enc, err := newSnapshotEncoder(formatOf(path), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range snaps {
+		if err := enc.Encode(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readAll reads every snapshot at path
+func readAll(t *testing.T, path string) []NormalizedSnapshot {
+	t.Helper()
+
+	var got []NormalizedSnapshot
+	if err := ReadSnapshots(path, func(s NormalizedSnapshot) error {
+		got = append(got, s)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func assertSnapshots(t *testing.T, got, want []NormalizedSnapshot) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d snapshots, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.Timestamp.Equal(w.Timestamp) {
+			t.Errorf("#%d timestamp = %v, want %v", i, g.Timestamp, w.Timestamp)
+		}
+		g.Timestamp, w.Timestamp = time.Time{}, time.Time{}
+		if g != w {
+			t.Errorf("#%d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshots."+format)
+			writeSnapshots(t, path, testSnapshots()...)
+			assertSnapshots(t, readAll(t, path), testSnapshots())
+		})
+	}
+}
+
+func TestReadLegacyCSV(t *testing.T) {
+	// 没有 fx_rate 与 unit 列的旧文件
+	data := "symbol,last_price,last_price_cny,open,high,low,volume,turnover,timestamp,status\n" +
+		"XAUUSD,2650,589.6,2640,2655,2638,1200,3180000,2026-03-02T09:30:00Z,0\n"
+	path := filepath.Join(t.TempDir(), "legacy.csv")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readAll(t, path)
+	if len(got) != 1 {
+		t.Fatalf("got %d snapshots, want 1", len(got))
+	}
+	s := got[0]
+	if s.LastPrice != 2650 || s.Unit != "" || s.GetUnit() != "USD/oz" {
+		t.Errorf("got %+v", s)
+	}
+	// 汇率由 last_price_cny 反推
+	if want := 589.6 / 2650 * config.UnitGrams["oz"]; math.Abs(s.FXRate-want) > 1e-9 {
+		t.Errorf("fx rate = %v, want %v", s.FXRate, want)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.csv")
+	if err := os.WriteFile(bad, []byte("XAUUSD,2650,589.6\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadSnapshots(bad, func(NormalizedSnapshot) error { return nil }); err == nil {
+		t.Error("want an error for a record with too few fields")
+	}
+}
+
+func TestReadSnapshotsOrder(t *testing.T) {
+	dir := t.TempDir()
+	snaps := testSnapshots()
+
+	// 文件按名称而不是创建顺序读取，非快照文件被忽略
+	writeSnapshots(t, filepath.Join(dir, "snapshots-20260302T100000.jsonl"), snaps[1])
+	writeSnapshots(t, filepath.Join(dir, "snapshots-20260302T090000.jsonl"), snaps[0])
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a snapshot\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	assertSnapshots(t, readAll(t, dir), snaps)
+	assertSnapshots(t, readAll(t, filepath.Join(dir, "snapshots-*.jsonl")), snaps)
+	assertSnapshots(t, readAll(t, filepath.Join(dir, "snapshots-20260302T100000.jsonl")), snaps[1:])
+
+	if err := ReadSnapshots(filepath.Join(dir, "*.csv"), func(NormalizedSnapshot) error { return nil }); err == nil {
+		t.Error("want an error when no snapshot files match")
+	}
+}
+
+func TestRecorderRotation(t *testing.T) {
+	market := &config.MarketConfig{Timezone: "Asia/Shanghai"}
+
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			r, err := NewRecorder(&config.RecorderConfig{Dir: dir, Format: format, Rotate: 24 * time.Hour}, market)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 前两条在北京时间 3 月 2 日 17:30，第三条 16:30 UTC 已是北京时间 3 月 3 日，
+			// 第四条迟到的快照留在当前文件
+			snaps := testSnapshots()
+			snaps = append(snaps, snaps[0], snaps[1])
+			snaps[2].Timestamp = snaps[0].Timestamp.Add(7 * time.Hour)
+
+			for _, s := range snaps {
+				r.Record(s)
+			}
+			r.Close()
+
+			for name, want := range map[string][]NormalizedSnapshot{
+				"snapshots-20260302T000000." + format: snaps[:2],
+				"snapshots-20260303T000000." + format: snaps[2:],
+			} {
+				assertSnapshots(t, readAll(t, filepath.Join(dir, name)), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/logger"
+)
+
+// Recorder writes snapshots to rotating JSONL/CSV files in the background.
+// Files are rotated on the snapshot timestamps in the market timezone and named
+// snapshots-<start>.<format> so that a directory sorts in time order.
+type Recorder struct {
+	dir    string
+	format string
+	rotate time.Duration
+	loc    *time.Location
+
+	ch   chan NormalizedSnapshot
+	wg   sync.WaitGroup
+	once sync.Once
+
+	file    *os.File
+	encoder snapshotEncoder
+	period  time.Time // Start of the period covered by the current file
+}
+
+// NewRecorder creates a recorder rotating on the wall clock of market and starts its writer goroutine
+func NewRecorder(cfg *config.RecorderConfig, market *config.MarketConfig) (*Recorder, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("recorder dir is required")
+	}
+
+	format := cfg.Format
+	if format == "" {
+		format = FormatJSONL
+	}
+	if format != FormatJSONL && format != FormatCSV {
+		return nil, fmt.Errorf("unknown recorder format: %s", format)
+	}
+
+	rotate := cfg.Rotate
+	if rotate <= 0 {
+		rotate = 24 * time.Hour
+	}
+
+	bufferSize := cfg.Buffer
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recorder dir: %w", err)
+	}
+
+	r := &Recorder{
+		dir:    cfg.Dir,
+		format: format,
+		rotate: rotate,
+		loc:    market.GetLocation(),
+		ch:     make(chan NormalizedSnapshot, bufferSize),
+	}
+
+	r.wg.Add(1)
+	go r.run()
+	return r, nil
+}
+
+// Record queues a snapshot for writing (non-blocking)
+func (r *Recorder) Record(snap NormalizedSnapshot) {
+	select {
+	case r.ch <- snap:
+	default:
+		logger.Warnf("recorder buffer full, dropping snapshot for %s", snap.Symbol)
+	}
+}
+
+func (r *Recorder) run() {
+	defer r.wg.Done()
+
+	for snap := range r.ch {
+		if err := r.write(snap); err != nil {
+			logger.Errorf("[recorder] failed to write snapshot for %s: %v", snap.Symbol, err)
+		}
+
+		// 批量写入，队列空闲时再刷盘
+		if len(r.ch) == 0 && r.encoder != nil {
+			if err := r.encoder.Flush(); err != nil {
+				logger.Errorf("[recorder] failed to flush: %v", err)
+			}
+		}
+	}
+
+	r.closeFile()
+}
+
+func (r *Recorder) write(snap NormalizedSnapshot) error {
+	period := r.periodOf(snap.Timestamp)
+	// 迟到的快照写入当前文件，不回到已轮转的文件
+	if r.encoder == nil || period.After(r.period) {
+		if err := r.openFile(period); err != nil {
+			return err
+		}
+	}
+	return r.encoder.Encode(snap)
+}
+
+// periodOf returns the start of the rotation period containing t. Periods are aligned to
+// midnight on the wall clock of the market timezone, so they stay aligned across DST changes.
+func (r *Recorder) periodOf(t time.Time) time.Time {
+	local := t.In(r.loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, r.loc)
+
+	if r.rotate >= 24*time.Hour {
+		// 多日轮转按公历日序号对齐
+		days := int64(r.rotate / (24 * time.Hour))
+		n := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+		return midnight.AddDate(0, 0, -int(n%days))
+	}
+
+	wall := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+	offset := wall.Truncate(r.rotate)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, int(offset), r.loc)
+}
+
+// openFile rotates to the file covering period
+func (r *Recorder) openFile(period time.Time) error {
+	r.closeFile()
+
+	name := fmt.Sprintf("snapshots-%s.%s", period.Format("20060102T150405"), r.format)
+	path := filepath.Join(r.dir, name)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open record file: %w", err)
+	}
+
+	encoder, err := newSnapshotEncoder(r.format, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	logger.Debugf("[recorder] writing snapshots to %s", path)
+	r.file, r.encoder, r.period = f, encoder, period
+	return nil
+}
+
+func (r *Recorder) closeFile() {
+	if r.file == nil {
+		return
+	}
+	if err := r.encoder.Flush(); err != nil {
+		logger.Errorf("[recorder] failed to flush: %v", err)
+	}
+	if err := r.file.Close(); err != nil {
+		logger.Errorf("[recorder] failed to close file: %v", err)
+	}
+	r.file, r.encoder = nil, nil
+}
+
+// Close flushes pending snapshots and closes the current file.
+// Record must not be called after Close.
+func (r *Recorder) Close() {
+	r.once.Do(func() {
+		close(r.ch)
+		r.wg.Wait()
+	})
+}
@@ -0,0 +1,119 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/logger"
+)
+
+// ReplaySource feeds recorded snapshot files back as a Source.
+// Snapshots are paced by their recorded timestamps divided by the configured speed,
+// or emitted as fast as the consumer reads them when speed is 0.
+type ReplaySource struct {
+	cfg *config.ReplayConfig
+
+	snapshots chan NormalizedSnapshot
+	cancel    context.CancelFunc
+	done      chan struct{}
+	mu        sync.Mutex
+	started   bool
+}
+
+var _ Source = (*ReplaySource)(nil)
+
+// NewReplaySource creates a new ReplaySource instance
+func NewReplaySource(cfg *config.ReplayConfig) (*ReplaySource, error) {
+	if cfg == nil || cfg.Path == "" {
+		return nil, fmt.Errorf("replay path is required")
+	}
+	if cfg.Speed < 0 {
+		return nil, fmt.Errorf("replay speed must not be negative")
+	}
+
+	return &ReplaySource{
+		cfg:       cfg,
+		snapshots: make(chan NormalizedSnapshot),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Start begins replaying in the background; the snapshot channel is closed at the end of the data
+func (s *ReplaySource) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("source already started")
+	}
+	if _, err := expandPath(s.cfg.Path); err != nil {
+		return err
+	}
+	s.started = true
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	go s.run(ctx)
+
+	logger.Debugf("ReplaySource started, path=%s speed=%v", s.cfg.Path, s.cfg.Speed)
+	return nil
+}
+
+func (s *ReplaySource) run(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.snapshots)
+
+	var (
+		count    int
+		prevTime time.Time
+	)
+
+	err := ReadSnapshots(s.cfg.Path, func(snap NormalizedSnapshot) error {
+		if s.cfg.Speed > 0 && !prevTime.IsZero() {
+			if wait := time.Duration(float64(snap.Timestamp.Sub(prevTime)) / s.cfg.Speed); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		prevTime = snap.Timestamp
+
+		select {
+		case s.snapshots <- snap:
+			count++
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		logger.Errorf("replay stopped: %v", err)
+	}
+	logger.Infof("replay finished, %d snapshots replayed", count)
+}
+
+// Snapshots returns a read-only channel for receiving replayed snapshots
+func (s *ReplaySource) Snapshots() <-chan NormalizedSnapshot {
+	return s.snapshots
+}
+
+// Close stops the replay and waits for it to exit
+func (s *ReplaySource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		return nil
+	}
+
+	s.cancel()
+	<-s.done
+	s.started = false
+	return nil
+}
@@ -6,34 +6,35 @@ import (
 	"time"
 
 	"github.com/qos-max/qos-quote-api-go-sdk/qosapi"
+
+	"github.com/wangpf09/golddog/pkg/config"
 )
 
 // NormalizedSnapshot contains typed numeric fields converted from raw data
 type NormalizedSnapshot struct {
-	Symbol       string
-	LastPrice    float64
-	LastPriceCNY float64
-	Open         float64
-	High         float64
-	Low          float64
-	Volume       float64
-	Turnover     float64
-	Timestamp    time.Time
-	Status       int // 0=normal, 1=suspended
+	Symbol       string    `json:"symbol"`
+	LastPrice    float64   `json:"last_price"`
+	LastPriceCNY float64   `json:"last_price_cny"` // LastPrice in CNY/g
+	Unit         string    `json:"unit"`           // Price unit of the quoted prices, empty means USD/oz
+	FXRate       float64   `json:"fx_rate"`        // USD/CNY rate the prices are converted with
+	Open         float64   `json:"open"`
+	High         float64   `json:"high"`
+	Low          float64   `json:"low"`
+	Volume       float64   `json:"volume"`
+	Turnover     float64   `json:"turnover"`
+	Timestamp    time.Time `json:"timestamp"`
+	Status       int       `json:"status"` // 0=normal, 1=suspended
 }
 
-const (
-	// Currency conversion
-	usdToCnyRate     = 6.92    // 美元转人民币汇率
-	troyOunceToGrams = 31.1035 // 1盎司 = 31.1035克
-)
-
-// FromWSSnapshot converts a RawSnapshot (qosapi.WSSnapshot) to NormalizedSnapshot
-func FromWSSnapshot(snapshot qosapi.WSSnapshot) (NormalizedSnapshot, error) {
+// FromWSSnapshot converts a RawSnapshot (qosapi.WSSnapshot) to NormalizedSnapshot.
+// The prices are quoted as described by inst; fxRate is the USD/CNY rate for conversions.
+func FromWSSnapshot(snapshot qosapi.WSSnapshot, inst config.InstrumentConfig, fxRate float64) (NormalizedSnapshot, error) {
 	normalized := NormalizedSnapshot{
 		Symbol:    snapshot.Code,
 		Timestamp: time.Unix(snapshot.Timestamp, 0),
 		Status:    snapshot.Suspended,
+		Unit:      inst.PriceUnit(),
+		FXRate:    fxRate,
 	}
 
 	// Convert all string fields to float64
@@ -44,7 +45,7 @@ func FromWSSnapshot(snapshot qosapi.WSSnapshot) (NormalizedSnapshot, error) {
 		return normalized, err
 	}
 
-	normalized.LastPriceCNY = (normalized.LastPrice * usdToCnyRate) / troyOunceToGrams
+	normalized.LastPriceCNY, _ = normalized.Price("CNY/g")
 
 	normalized.Open, err = parseFloat(snapshot.Open, "o")
 	if err != nil {
@@ -74,6 +75,60 @@ func FromWSSnapshot(snapshot qosapi.WSSnapshot) (NormalizedSnapshot, error) {
 	return normalized, nil
 }
 
+// GetUnit returns the price unit of the quoted prices
+func (s NormalizedSnapshot) GetUnit() string {
+	if s.Unit == "" {
+		return "USD/oz"
+	}
+	return s.Unit
+}
+
+// Price returns LastPrice converted to unit, e.g. CNY/kg. It fails for unknown units and
+// for conversions between currencies without an FX rate.
+func (s NormalizedSnapshot) Price(unit string) (float64, bool) {
+	price, err := ConvertPrice(s.LastPrice, s.GetUnit(), unit, s.FXRate)
+	return price, err == nil
+}
+
+// ConvertPrice converts price from one price unit to another, e.g. USD/oz to CNY/g.
+// fxRate is the USD/CNY rate, only needed when the currencies differ.
+func ConvertPrice(price float64, from, to string, fxRate float64) (float64, error) {
+	if from == to {
+		return price, nil
+	}
+
+	fromCurrency, fromUnit, err := config.ParsePriceUnit(from)
+	if err != nil {
+		return 0, err
+	}
+	toCurrency, toUnit, err := config.ParsePriceUnit(to)
+	if err != nil {
+		return 0, err
+	}
+
+	// 先换算到每克价格，再换币种
+	price = price / config.UnitGrams[fromUnit] * config.UnitGrams[toUnit]
+
+	switch {
+	case fromCurrency == toCurrency:
+	case fxRate <= 0:
+		return 0, fmt.Errorf("no FX rate to convert %s to %s", from, to)
+	case fromCurrency == config.CurrencyUSD:
+		price *= fxRate
+	default:
+		price /= fxRate
+	}
+	return price, nil
+}
+
+// backfillFXRate derives the FX rate of snapshots recorded before it was stored
+func (s *NormalizedSnapshot) backfillFXRate() {
+	if s.FXRate != 0 || s.LastPrice <= 0 || s.LastPriceCNY <= 0 || s.GetUnit() != "USD/oz" {
+		return
+	}
+	s.FXRate = s.LastPriceCNY / s.LastPrice * config.UnitGrams["oz"]
+}
+
 // parseFloat helper to convert string to float64 with error context
 func parseFloat(s string, fieldName string) (float64, error) {
 	if s == "" {
@@ -91,7 +146,8 @@ func parseFloat(s string, fieldName string) (float64, error) {
 type Derived struct {
 	PriceChange     float64 // Δp
 	PriceChangeRate float64
-	VolumeDelta     float64 // Δv
+	VolumeDelta     float64       // Δv
+	Dt              time.Duration // Δt between the two snapshots
 }
 
 func NewDerived(lastSnapshot, snapshot NormalizedSnapshot) Derived {
@@ -102,5 +158,6 @@ func NewDerived(lastSnapshot, snapshot NormalizedSnapshot) Derived {
 		PriceChange:     priceChange,
 		PriceChangeRate: priceChangeRate,
 		VolumeDelta:     volumeDelta,
+		Dt:              snapshot.Timestamp.Sub(lastSnapshot.Timestamp),
 	}
 }
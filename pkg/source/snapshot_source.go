@@ -3,25 +3,90 @@ package source
 import (
 	"context"
 	"fmt"
+	"math"
+	"math/rand"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/qos-max/qos-quote-api-go-sdk/qosapi"
 
 	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/fx"
 	"github.com/wangpf09/golddog/pkg/logger"
 )
 
-// SnapshotSource manages WebSocket connection to qosapi and provides snapshot data
+// superviseInterval 连接健康检查的轮询间隔
+const superviseInterval = time.Second
+
+// wsClient is the part of the qosapi WebSocket client used by SnapshotSource
+type wsClient interface {
+	Connect() error
+	StartHeartbeat(interval time.Duration)
+	SubscribeSnapshot(codes []string, handler func(qosapi.WSSnapshot)) error
+	Close()
+}
+
+// dialer creates an unconnected client
+type dialer func() wsClient
+
+// qosClient adapts *qosapi.WSClient to wsClient
+type qosClient struct {
+	c *qosapi.WSClient
+}
+
+func (q qosClient) Connect() error                        { return q.c.Connect() }
+func (q qosClient) StartHeartbeat(interval time.Duration) { q.c.StartHeartbeat(interval) }
+func (q qosClient) Close()                                { q.c.Close() }
+
+func (q qosClient) SubscribeSnapshot(codes []string, handler func(qosapi.WSSnapshot)) error {
+	return q.c.SubscribeSnapshot(codes, handler)
+}
+
+// dialQOS returns a dialer for the qosapi WebSocket
+func dialQOS(apiKey string) dialer {
+	return func() wsClient {
+		return qosClient{qosapi.NewWSClient(apiKey)}
+	}
+}
+
+// SnapshotSource manages WebSocket connection to qosapi and provides snapshot data.
+// The connection is supervised: the SDK does not report read or heartbeat failures, so a
+// dead connection is detected by the idle timeout. When no snapshot arrives within it while
+// the market is open, the client is re-created with jittered exponential backoff and all
+// symbols are resubscribed.
 type SnapshotSource struct {
 	cfg *config.QOSConfig
 
-	client *qosapi.WSClient
+	dial        dialer
+	client      wsClient
+	recorder    *Recorder
+	fx          *fx.Rates
+	instruments config.Instruments
+	market      *config.MarketConfig
 
 	snapshots chan NormalizedSnapshot
+	status    chan ConnEvent
+	done      chan struct{}
 	mu        sync.RWMutex
 	started   bool
+
+	lastData atomic.Int64 // 最近一次收到快照的时间（UnixNano）
+}
+
+// connState is the supervisor's view of the connection
+type connState struct {
+	downSince time.Time // 断线开始时间，零值表示连接正常
+	attempts  int
+	nextTry   time.Time
 }
 
+var (
+	_ StatusReporter = (*SnapshotSource)(nil)
+	_ FXReporter     = (*SnapshotSource)(nil)
+)
+
 // NewSnapshotSource creates a new SnapshotSource instance
 func NewSnapshotSource(cfg *config.QOSConfig) (*SnapshotSource, error) {
 	if cfg.APIKey == "" {
@@ -39,10 +104,44 @@ func NewSnapshotSource(cfg *config.QOSConfig) (*SnapshotSource, error) {
 
 	return &SnapshotSource{
 		cfg:       cfg,
+		dial:      dialQOS(cfg.APIKey),
+		fx:        fx.NewRates(nil),
 		snapshots: make(chan NormalizedSnapshot, bufferSize),
+		status:    make(chan ConnEvent, 16),
+		done:      make(chan struct{}),
 	}, nil
 }
 
+// SetRecorder makes the source write every normalized snapshot to r.
+// It must be called before Start; the recorder is closed together with the source.
+func (s *SnapshotSource) SetRecorder(r *Recorder) {
+	s.recorder = r
+}
+
+// SetFX replaces the default USD/CNY rate. When the rates stream an FX symbol, the symbol
+// is subscribed as well; its snapshots only feed the rate unless it is also a configured symbol.
+// It must be called before Start.
+func (s *SnapshotSource) SetFX(rates *fx.Rates) {
+	s.fx = rates
+}
+
+// SetInstruments sets how the symbols are quoted; symbols without an entry are USD/oz.
+// It must be called before Start.
+func (s *SnapshotSource) SetInstruments(instruments config.Instruments) {
+	s.instruments = instruments
+}
+
+// SetMarket sets the trading sessions; no snapshots outside them is not a disconnect.
+// It must be called before Start.
+func (s *SnapshotSource) SetMarket(market *config.MarketConfig) {
+	s.market = market
+}
+
+// FX returns the rates snapshots are converted with
+func (s *SnapshotSource) FX() *fx.Rates {
+	return s.fx
+}
+
 // Start initializes the WebSocket client, connects, and starts receiving snapshots
 func (s *SnapshotSource) Start(ctx context.Context) error {
 	s.mu.Lock()
@@ -53,81 +152,262 @@ func (s *SnapshotSource) Start(ctx context.Context) error {
 	s.started = true
 	s.mu.Unlock()
 
-	// Initialize qosapi WebSocket client
-	s.client = qosapi.NewWSClient(s.cfg.APIKey)
-
-	// Connect to WebSocket
-	logger.Debugf("正在连接到 WebSocket 服务器...")
 	logger.Debugf("Symbols: %v", s.cfg.Symbols)
 
-	if err := s.client.Connect(); err != nil {
+	client, err := s.connect()
+	if err != nil {
 		s.mu.Lock()
 		s.started = false
 		s.mu.Unlock()
+		return err
+	}
+
+	s.mu.Lock()
+	s.client = client
+	s.mu.Unlock()
+	s.lastData.Store(time.Now().UnixNano())
+	s.fx.Start(ctx)
+
+	// Supervise the connection and handle context cancellation
+	go s.supervise(ctx)
+
+	logger.Debugf("SnapshotSource started, monitoring %d symbols", len(s.cfg.Symbols))
+	return nil
+}
+
+// connect creates a new qosapi client, connects, starts the heartbeat and subscribes all symbols
+func (s *SnapshotSource) connect() (wsClient, error) {
+	// Initialize qosapi WebSocket client
+	client := s.dial()
 
+	// Connect to WebSocket
+	logger.Debugf("正在连接到 WebSocket 服务器...")
+
+	if err := client.Connect(); err != nil {
 		// Provide detailed error information
 		logger.Debugf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 		logger.Debugf("❌ WebSocket 连接失败: %v", err)
 		logger.Debugf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-		return fmt.Errorf("failed to connect: %w", err)
+		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
 
 	logger.Debugf("WebSocket connected")
 
-	// Start heartbeat to keep connection alive (every 30 seconds)
+	// Start heartbeat to keep connection alive
 	logger.Debugf("Starting heartbeat...")
-	s.client.StartHeartbeat(s.cfg.GetHeartbeatDuration())
+	client.StartHeartbeat(s.cfg.GetHeartbeatDuration())
 
 	// Subscribe to symbols for snapshot data
-	if err := s.subscribeSymbols(); err != nil {
-		s.client.Close()
-		s.mu.Lock()
-		s.started = false
-		s.mu.Unlock()
-		return fmt.Errorf("failed to subscribe: %w", err)
+	if err := s.subscribeSymbols(client); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
 	}
 
-	// Handle context cancellation
-	go func() {
-		<-ctx.Done()
-		logger.Errorf("Context cancelled, closing connection...")
-		s.Close()
-	}()
+	return client, nil
+}
 
-	logger.Debugf("SnapshotSource started, monitoring %d symbols", len(s.cfg.Symbols))
+// supervise watches the connection and reconnects when it fails or the feed goes quiet.
+// A disconnect is reported once; recovery is reported when data flows again after reconnecting.
+func (s *SnapshotSource) supervise(ctx context.Context) {
+	ticker := time.NewTicker(superviseInterval)
+	defer ticker.Stop()
+
+	var st connState
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Errorf("Context cancelled, closing connection...")
+			s.Close()
+			return
+
+		case <-s.done:
+			return
+
+		case now := <-ticker.C:
+			s.check(now, &st)
+		}
+	}
+}
+
+// check advances the supervisor state at now
+func (s *SnapshotSource) check(now time.Time, st *connState) {
+	idle := s.cfg.GetIdleTimeout()
+	last := time.Unix(0, s.lastData.Load())
+	open, trading := s.market.SessionAt(now)
+
+	if st.downSince.IsZero() {
+		// 休市期间没有行情是正常的
+		if !trading {
+			return
+		}
+		// 开盘前的静默不计入无数据时间
+		since := last
+		if open.After(since) {
+			since = open
+		}
+		if now.Sub(since) < idle {
+			return
+		}
+		reason := fmt.Errorf("no snapshot received for %v", now.Sub(since).Round(time.Second))
+
+		st.downSince, st.attempts, st.nextTry = now, 0, now
+		logger.Warnf("quote connection lost, reconnecting: %v", reason)
+		s.emitStatus(ConnEvent{Connected: false, Err: reason, Time: now})
+	}
+
+	// 重连后数据已恢复
+	if last.After(st.downSince) {
+		s.recovered(now, st)
+		return
+	}
+
+	if now.Before(st.nextTry) {
+		return
+	}
+
+	st.attempts++
+	wait := s.calcBackoff(st.attempts)
+	if err := s.reconnect(); err != nil {
+		logger.Warnf("reconnect attempt %d failed, retry in %v: %v", st.attempts, wait, err)
+		st.nextTry = now.Add(wait)
+		return
+	}
+
+	// 休市时没有数据可等，连接成功即视为恢复
+	if !trading {
+		s.recovered(now, st)
+		return
+	}
+
+	// 连接成功但仍需等待数据到达，超时未到则继续重连
+	st.nextTry = now.Add(max(idle, wait))
+}
+
+// recovered reports the end of an outage and resets the supervisor state
+func (s *SnapshotSource) recovered(now time.Time, st *connState) {
+	logger.Infof("snapshot feed recovered after %v (%d attempts)", now.Sub(st.downSince).Round(time.Second), st.attempts)
+	s.emitStatus(ConnEvent{
+		Connected: true,
+		Attempts:  st.attempts,
+		Downtime:  now.Sub(st.downSince),
+		Time:      now,
+	})
+	*st = connState{}
+}
+
+// reconnect replaces the current client with a freshly connected and subscribed one
+func (s *SnapshotSource) reconnect() error {
+	s.mu.Lock()
+	old := s.client
+	s.client = nil
+	s.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+
+	client, err := s.connect()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Close may have been called while connecting
+	if !s.started {
+		client.Close()
+		return fmt.Errorf("source closed")
+	}
+	s.client = client
 	return nil
 }
 
-// subscribeSymbols subscribes to all configured symbols for snapshot data
-func (s *SnapshotSource) subscribeSymbols() error {
+// calcBackoff 计算第 attempt 次重连的退避时间（指数增长，+/- 20% 抖动）
+func (s *SnapshotSource) calcBackoff(attempt int) time.Duration {
+	initial, maximum := s.cfg.GetReconnectBackoff()
+
+	backoff := float64(initial) * math.Pow(2, float64(attempt-1))
+	if backoff > float64(maximum) {
+		backoff = float64(maximum)
+	}
+
+	jitter := (rand.Float64()*0.4 - 0.2) * backoff
+	return time.Duration(backoff + jitter)
+}
+
+// emitStatus 非阻塞上报连接状态变化
+func (s *SnapshotSource) emitStatus(e ConnEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.started {
+		return
+	}
+
+	select {
+	case s.status <- e:
+	default:
+		logger.Warnf("Warning: status channel full, dropping connection event")
+	}
+}
+
+// subscribeSymbols subscribes to all configured symbols and the FX symbol for snapshot data
+func (s *SnapshotSource) subscribeSymbols(client wsClient) error {
 	if len(s.cfg.Symbols) == 0 {
 		return fmt.Errorf("no symbols to subscribe")
 	}
 
-	logger.Debugf("Subscribing to symbols: %v", s.cfg.Symbols)
+	symbols := s.cfg.Symbols
+	if fxSymbol := s.fx.Symbol(); fxSymbol != "" && !slices.Contains(symbols, fxSymbol) {
+		symbols = append(slices.Clone(symbols), fxSymbol)
+	}
+
+	logger.Debugf("Subscribing to symbols: %v", symbols)
 
 	// Subscribe to snapshot data for all symbols with callback
 	// The SDK supports batch subscription
-	if err := s.client.SubscribeSnapshot(s.cfg.Symbols, s.handleSnapshot); err != nil {
+	if err := client.SubscribeSnapshot(symbols, s.handleSnapshot); err != nil {
 		return fmt.Errorf("failed to subscribe to snapshots: %w", err)
 	}
 
-	logger.Debugf("Successfully subscribed to %d symbols", len(s.cfg.Symbols))
+	logger.Debugf("Successfully subscribed to %d symbols", len(symbols))
 	return nil
 }
 
 // handleSnapshot processes incoming snapshot data (non-blocking)
 func (s *SnapshotSource) handleSnapshot(wsSnapshot qosapi.WSSnapshot) {
-	// Convert qosapi.WSSnapshot to internal RawSnapshot format
+	now := time.Now()
+	s.lastData.Store(now.UnixNano())
 
 	// Convert to NormalizedSnapshot
-	normalized, err := FromWSSnapshot(wsSnapshot)
+	normalized, err := FromWSSnapshot(wsSnapshot, s.instruments.Get(wsSnapshot.Code), s.fx.Rate(now).Rate)
 	if err != nil {
 		logger.Errorf("Failed to normalize snapshot for %s: %v", wsSnapshot.Code, err)
 		return
 	}
 
+	// 汇率品种只用于更新汇率，除非同时配置为监控品种
+	if normalized.Symbol == s.fx.Symbol() {
+		s.fx.Update(fx.Quote{Rate: normalized.LastPrice, Time: now, Source: normalized.Symbol})
+		if !slices.Contains(s.cfg.Symbols, normalized.Symbol) {
+			return
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Drop late callbacks once the channel is closed
+	if !s.started {
+		return
+	}
+
+	if s.recorder != nil {
+		s.recorder.Record(normalized)
+	}
+
 	// Non-blocking send to channel
 	select {
 	case s.snapshots <- normalized:
@@ -143,25 +423,41 @@ func (s *SnapshotSource) Snapshots() <-chan NormalizedSnapshot {
 	return s.snapshots
 }
 
+// Status returns a read-only channel reporting disconnects and recoveries
+func (s *SnapshotSource) Status() <-chan ConnEvent {
+	return s.status
+}
+
 // Close gracefully shuts down the WebSocket connection and closes channels
 func (s *SnapshotSource) Close() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.started {
+		s.mu.Unlock()
 		return nil
 	}
 
 	logger.Debugf("Closing SnapshotSource...")
 
-	if s.client != nil {
-		s.client.Close()
-	}
-
-	// Close the snapshot channel
+	// Stop the supervisor and close the channels; late callbacks are dropped in handleSnapshot
+	close(s.done)
 	close(s.snapshots)
+	close(s.status)
 	s.started = false
 
+	client := s.client
+	s.client = nil
+	s.mu.Unlock()
+
+	// Close the client outside the lock so in-flight callbacks can finish
+	if client != nil {
+		client.Close()
+	}
+
+	if s.recorder != nil {
+		s.recorder.Close()
+	}
+	s.fx.Close()
+
 	logger.Debugf("SnapshotSource closed")
 	return nil
 }
@@ -0,0 +1,163 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qos-max/qos-quote-api-go-sdk/qosapi"
+
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+// fakeClient records whether it was closed
+type fakeClient struct {
+	closed bool
+}
+
+func (c *fakeClient) Connect() error                                            { return nil }
+func (c *fakeClient) StartHeartbeat(time.Duration)                              {}
+func (c *fakeClient) SubscribeSnapshot([]string, func(qosapi.WSSnapshot)) error { return nil }
+func (c *fakeClient) Close()                                                    { c.closed = true }
+
+// newTestSource returns a started source whose clients are fakes, connected at t0
+func newTestSource(t *testing.T, market *config.MarketConfig, t0 time.Time) (*SnapshotSource, *[]*fakeClient) {
+	t.Helper()
+
+	s, err := NewSnapshotSource(&config.QOSConfig{
+		APIKey:                  "test",
+		Symbols:                 []string{"XAUUSD"},
+		IdleTimeout:             30 * time.Second,
+		ReconnectInitialBackoff: time.Second,
+		ReconnectMaxBackoff:     time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var clients []*fakeClient
+	s.dial = func() wsClient {
+		c := &fakeClient{}
+		clients = append(clients, c)
+		return c
+	}
+	s.SetMarket(market)
+
+	s.started = true
+	if s.client, err = s.connect(); err != nil {
+		t.Fatal(err)
+	}
+	s.lastData.Store(t0.UnixNano())
+	return s, &clients
+}
+
+// nextStatus returns the pending connection event, if any
+func nextStatus(s *SnapshotSource) (ConnEvent, bool) {
+	select {
+	case e := <-s.status:
+		return e, true
+	default:
+		return ConnEvent{}, false
+	}
+}
+
+func TestCalcBackoff(t *testing.T) {
+	s := &SnapshotSource{cfg: &config.QOSConfig{ReconnectInitialBackoff: time.Second, ReconnectMaxBackoff: 30 * time.Second}}
+
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 16 * time.Second},
+		{6, 30 * time.Second}, // 上限
+		{20, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 100 {
+			got := s.calcBackoff(tt.attempt)
+			lo, hi := tt.base*8/10, tt.base*12/10
+			if got < lo || got > hi {
+				t.Fatalf("calcBackoff(%d) = %v, want within [%v, %v]", tt.attempt, got, lo, hi)
+			}
+		}
+	}
+}
+
+func TestSuperviseIdle(t *testing.T) {
+	t0 := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	s, clients := newTestSource(t, nil, t0)
+	var st connState
+
+	s.check(t0.Add(20*time.Second), &st)
+	if _, ok := nextStatus(s); ok {
+		t.Fatal("reported a disconnect before the idle timeout")
+	}
+
+	s.check(t0.Add(31*time.Second), &st)
+	e, ok := nextStatus(s)
+	if !ok || e.Connected || e.Err == nil {
+		t.Fatalf("want a disconnect after the idle timeout, got %+v (%v)", e, ok)
+	}
+	if len(*clients) != 2 || !(*clients)[0].closed {
+		t.Fatalf("want the old client closed and one reconnect, got %d clients", len(*clients))
+	}
+
+	// 重连后数据到达
+	s.lastData.Store(t0.Add(35 * time.Second).UnixNano())
+	s.check(t0.Add(36*time.Second), &st)
+	e, ok = nextStatus(s)
+	if !ok || !e.Connected || e.Attempts != 1 || e.Downtime != 5*time.Second {
+		t.Fatalf("want recovery after 1 attempt and 5s, got %+v (%v)", e, ok)
+	}
+	if !st.downSince.IsZero() {
+		t.Fatal("state not reset after recovery")
+	}
+}
+
+func TestSuperviseMarketClosed(t *testing.T) {
+	market := &config.MarketConfig{
+		Timezone: "UTC",
+		Sessions: []config.SessionConfig{{Name: "Day", Open: "09:00", Close: "17:00"}},
+		Days:     []string{"Mon", "Tue", "Wed", "Thu", "Fri"},
+	}
+
+	// 周五收盘后到周一开盘前没有数据不算断线
+	friday := time.Date(2026, 3, 6, 16, 59, 0, 0, time.UTC)
+	s, clients := newTestSource(t, market, friday)
+	var st connState
+
+	for _, at := range []time.Time{
+		friday.Add(time.Hour),
+		time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC), // 周六
+		time.Date(2026, 3, 9, 9, 0, 20, 0, time.UTC), // 周一开盘 20 秒
+	} {
+		s.check(at, &st)
+		if e, ok := nextStatus(s); ok {
+			t.Fatalf("%v: unexpected connection event %+v", at, e)
+		}
+	}
+
+	// 开盘后超时仍无数据
+	s.check(time.Date(2026, 3, 9, 9, 0, 31, 0, time.UTC), &st)
+	if e, ok := nextStatus(s); !ok || e.Connected {
+		t.Fatalf("want a disconnect 31s after the open, got %+v (%v)", e, ok)
+	}
+
+	// 收盘前断线，休市后重连成功即恢复
+	s, clients = newTestSource(t, market, friday.Add(-time.Minute))
+	st = connState{}
+	s.check(friday.Add(-29*time.Second), &st)
+	if e, ok := nextStatus(s); !ok || e.Connected {
+		t.Fatalf("want a disconnect, got %+v (%v)", e, ok)
+	}
+	s.check(friday.Add(time.Hour), &st)
+	if e, ok := nextStatus(s); !ok || !e.Connected || e.Attempts != 2 {
+		t.Fatalf("want recovery right after reconnecting, got %+v (%v)", e, ok)
+	}
+	if len(*clients) != 3 {
+		t.Fatalf("want two reconnects, got %d clients", len(*clients))
+	}
+}
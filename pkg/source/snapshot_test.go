@@ -0,0 +1,45 @@
+package source
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvertPrice(t *testing.T) {
+	tests := []struct {
+		price    float64
+		from, to string
+		fx       float64
+		want     float64
+		err      bool
+	}{
+		{2650, "USD/oz", "USD/oz", 0, 2650, false},
+		{3110.34768, "USD/oz", "CNY/g", 7, 700, false},
+		{3110.34768, "USD/oz", "CNY/kg", 7, 700000, false},
+		{700, "CNY/g", "USD/oz", 7, 3110.34768, false},
+		{612.5, "CNY/g", "CNY/kg", 0, 612500, false},
+		{2650, "USD/oz", "CNY/g", 0, 0, true}, // 缺少汇率
+		{2650, "USD/oz", "EUR/g", 7, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ConvertPrice(tt.price, tt.from, tt.to, tt.fx)
+		if (err != nil) != tt.err {
+			t.Errorf("%v %s -> %s: err %v, want error %v", tt.price, tt.from, tt.to, err, tt.err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("%v %s -> %s = %v, want %v", tt.price, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestBackfillFXRate(t *testing.T) {
+	// 记录 fx_rate 之前的快照由 last_price_cny 推算汇率
+	snap := NormalizedSnapshot{LastPrice: 2650, LastPriceCNY: 2650 * 6.92 / 31.1035}
+	snap.backfillFXRate()
+
+	if price, ok := snap.Price("CNY/g"); !ok || math.Abs(price-snap.LastPriceCNY) > 1e-9 {
+		t.Errorf("CNY/g = %v, want the recorded %v", price, snap.LastPriceCNY)
+	}
+}
@@ -0,0 +1,78 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/wangpf09/golddog/pkg/config"
+	"github.com/wangpf09/golddog/pkg/fx"
+)
+
+const (
+	TypeQOS    = "qos"
+	TypeReplay = "replay"
+)
+
+// Source produces normalized snapshots for the monitor.
+// Implementations must close the Snapshots channel once no more data will be delivered.
+type Source interface {
+	// Start begins producing snapshots until ctx is cancelled or Close is called
+	Start(ctx context.Context) error
+	// Snapshots returns a read-only channel for receiving normalized snapshots
+	Snapshots() <-chan NormalizedSnapshot
+	// Close stops the source and releases its resources
+	Close() error
+}
+
+// ConnEvent describes a change of the upstream connection state
+type ConnEvent struct {
+	Connected bool          // false = disconnected, true = recovered
+	Err       error         // Reason of the disconnect
+	Attempts  int           // Reconnect attempts before recovery
+	Downtime  time.Duration // How long the feed was down before recovery
+	Time      time.Time
+}
+
+// StatusReporter is implemented by sources that report connection state changes.
+// The channel is closed together with the source.
+type StatusReporter interface {
+	Status() <-chan ConnEvent
+}
+
+// FXReporter is implemented by sources converting prices with a live USD/CNY rate
+type FXReporter interface {
+	FX() *fx.Rates
+}
+
+var _ Source = (*SnapshotSource)(nil)
+
+// NewSource creates the Source selected by the source section of the config
+func NewSource(conf *config.Config) (Source, error) {
+	switch conf.Source.GetType() {
+	case TypeQOS:
+		src, err := NewSnapshotSource(conf.QOSConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		if conf.Recorder != nil && conf.Recorder.Enabled {
+			recorder, err := NewRecorder(conf.Recorder, conf.Market)
+			if err != nil {
+				return nil, err
+			}
+			src.SetRecorder(recorder)
+		}
+		src.SetInstruments(conf.Instruments)
+		src.SetMarket(conf.Market)
+		if conf.FX != nil {
+			src.SetFX(fx.NewRates(conf.FX))
+		}
+		return src, nil
+
+	case TypeReplay:
+		return NewReplaySource(conf.Source.Replay)
+	default:
+		return nil, fmt.Errorf("unknown source type: %s", conf.Source.GetType())
+	}
+}
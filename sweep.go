@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wangpf09/golddog/pkg/backtest"
+	"github.com/wangpf09/golddog/pkg/config"
+)
+
+// runSweep grid-searches detector parameters over a labeled snapshot dataset
+//
+//	golddog sweep -data data/ -labels labels.csv -spec sweep.yaml -format md -out sweep.md
+func runSweep(args []string) error {
+	fs := flag.NewFlagSet("sweep", flag.ContinueOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file")
+	data := fs.String("data", "", "recorded snapshot file, directory or glob")
+	labelsPath := fs.String("labels", "", "labeled events CSV (type,symbol,start,end)")
+	specPath := fs.String("spec", "", "sweep spec YAML")
+	format := fs.String("format", "md", "output format: md or csv")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *data == "" || *labelsPath == "" || *specPath == "" {
+		return fmt.Errorf("-data, -labels and -spec are required")
+	}
+	if *format != "md" && *format != "csv" {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+
+	if err := loadOfflineConfig(*configPath); err != nil {
+		return err
+	}
+
+	spec, err := backtest.LoadSweepSpec(*specPath)
+	if err != nil {
+		return err
+	}
+	labels, err := backtest.LoadLabels(*labelsPath)
+	if err != nil {
+		return err
+	}
+	snaps, err := backtest.Load(*data)
+	if err != nil {
+		return err
+	}
+
+	results, err := backtest.Sweep(config.GetConfig(), snaps, labels, spec)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "csv" {
+		return backtest.WriteSweepCSV(w, spec, results)
+	}
+	return backtest.WriteSweepMarkdown(w, spec, results)
+}